@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/aymanbagabas/go-osc52/v2"
 )
@@ -55,86 +57,94 @@ func ternary[T any](b bool, x, y T) T {
 }
 
 func FgANSI(c int) Modifier {
-	switch {
-	case c < 0:
-		return nil
-	case c < 8:
-		// 0-7  -> 30-37
-		return []byte(strconv.Itoa(c + 30))
-	case c < 15:
-		// 8-15 -> 90-97
-		return []byte(strconv.Itoa(c + 82))
-	case c < 256:
-		// 16-255
-		return []byte(fmt.Sprintf("38;5;%d", c))
-	default:
+	if c < 0 || c >= 256 {
 		return nil
 	}
+	return fgANSI[c]
 }
 
 func BgANSI(c int) Modifier {
-	switch {
-	case c < 0:
-		return nil
-	case c < 8:
-		// 0-7  -> 40-47
-		return []byte(strconv.Itoa(c + 40))
-	case c < 16:
-		// 8-15 -> 100-107
-		return []byte(strconv.Itoa(c + 92))
-	case c < 256:
-		// 16-255
-		return []byte(fmt.Sprintf("48;5;%d", c))
-	default:
+	if c < 0 || c >= 256 {
 		return nil
 	}
+	return bgANSI[c]
+}
+
+// fgANSI, bgANSI and ulANSI are interned modifiers for ANSI colors, so that
+// getting them doesn't allocate
+var fgANSI, bgANSI, ulANSI = ansiModifiers(30, 90, "38"), ansiModifiers(40, 100, "48"), ansiModifiers(-1, -1, "58")
+
+// ansiModifiers builds modifiers for 256 ANSI colors: 0-7 use normal codes, 8-15 use
+// bright codes, all other and all colors when codes are negative use extended code
+func ansiModifiers(normal, bright int, extended string) [256]Modifier {
+	var res [256]Modifier
+	for i := range res {
+		var mod Modifier
+		switch {
+		case i < 8 && normal >= 0:
+			// 0-7  -> 30-37
+			mod = strconv.AppendInt(nil, int64(normal+i), 10)
+		case i < 16 && bright >= 0:
+			// 8-15 -> 90-97
+			mod = strconv.AppendInt(nil, int64(bright+i-8), 10)
+		default:
+			// 16-255
+			mod = strconv.AppendInt([]byte(extended+";5;"), int64(i), 10)
+		}
+		// clip capacity so that appending to modifier doesn't overwrite shared one
+		res[i] = mod[:len(mod):len(mod)]
+	}
+	return res
+}
+
+// appendRGB appends 24-bit color modifier with given code to dst
+func appendRGB(dst []byte, code string, r, g, b uint8) []byte {
+	dst = append(dst, code...)
+	dst = append(dst, ";2;"...)
+	dst = strconv.AppendUint(dst, uint64(r), 10)
+	dst = append(dst, ';')
+	dst = strconv.AppendUint(dst, uint64(g), 10)
+	dst = append(dst, ';')
+	return strconv.AppendUint(dst, uint64(b), 10)
 }
 
 // MustParseHexRGB parses hex color string, in form "#f0c" or "#ff1034".
-// If color is invalid, returns black (or junk)
+// If color is invalid, returns black. Use ParseColor to check for errors.
 func MustParseHexRGB(hex string) (r, g, b uint8) {
-	isShort := len(hex) == 4
-
-	format := ternary(isShort, "#%1x%1x%1x", "#%02x%02x%02x")
-	n, err := fmt.Sscanf(hex, format, &r, &g, &b)
-	if err != nil || n != 3 {
-		// err = fmt.Errorf("color: %v is not a hex-color", hex)
-		return
-	}
-
-	factor := ternary(isShort, 15, uint8(1))
-	return r * factor, g * factor, b * factor
+	c, _ := parseHex(hex)
+	return c.RGB()
 }
 
 // r,g,b are 0-255
 func FgRGB(r, g, b uint8) Modifier {
-	return []byte(fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+	return appendRGB(make(Modifier, 0, len("38;2;255;255;255")), "38", r, g, b)
 }
 
 // r,g,b are 0-255
 func BgRGB(r, g, b uint8) Modifier {
-	return []byte(fmt.Sprintf("48;2;%d;%d;%d", r, g, b))
+	return appendRGB(make(Modifier, 0, len("48;2;255;255;255")), "48", r, g, b)
 }
 
+// UnderlineANSI sets underline color to ANSI color c, 0-255
+func UnderlineANSI(c int) Modifier {
+	if c < 0 || c >= 256 {
+		return nil
+	}
+	return ulANSI[c]
+}
+
+// UnderlineRGB sets underline color, r,g,b are 0-255
+func UnderlineRGB(r, g, b uint8) Modifier {
+	return appendRGB(make(Modifier, 0, len("58;2;255;255;255")), "58", r, g, b)
+}
+
+// ToHex returns hex value of color modifier, e.g. "#c0c0c0" for BgWhite
 func ToHex(color Modifier) string {
-	switch {
-	case !bytes.ContainsRune(color, ';'):
-		i, _ := strconv.Atoi(string(color))
-
-		// just some magic formula
-		j := i / 10
-		return ansiHex[i-10*j+j>>3<<3]
-	case color[3] == '5':
-		var skip, i int
-		fmt.Sscanf(string(color), "%d;5;%d", &skip, &i)
-		return ansiHex[i]
-	case color[3] == '2':
-		var skip, r, g, b int
-		fmt.Sscanf(string(color), "%d;2;%d;%d;%d", &skip, &r, &g, &b)
-		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
-	default:
+	c, ok := parseColorModifier(color)
+	if !ok {
 		return "invalid color"
 	}
+	return c.Hex()
 }
 
 var (
@@ -177,21 +187,59 @@ var (
 	BgHiWhite   = BgANSI(15)
 
 	// Common consts
-	_esc              byte = '\x1b'               // Escape character
-	_csi                   = Modifier{_esc, '['}  // Control Sequence Introducer
-	_osc                   = Modifier{_esc, ']'}  // Operating System Command
-	_stringTerminator      = Modifier{_esc, '\\'} // String Terminator
-	ModReset               = Modifier("0")
-	ModBold                = Modifier("1")
-	ModFaint               = Modifier("2")
-	ModItalic              = Modifier("3")
-	ModUnderline           = Modifier("4")
-	ModBlink               = Modifier("5")
-	ModReverse             = Modifier("7")
-	ModCrossout            = Modifier("9")
-	ModOverline            = Modifier("53")
+	_esc                byte = '\x1b'                        // Escape character
+	_csi                     = Modifier{_esc, '['}           // Control Sequence Introducer
+	_sgrReset                = Modifier{_esc, '[', '0', 'm'} // Reset all graphic attributes
+	_osc                     = Modifier{_esc, ']'}           // Operating System Command
+	_stringTerminator        = Modifier{_esc, '\\'}          // String Terminator
+	ModReset                 = Modifier("0")
+	ModBold                  = Modifier("1")
+	ModFaint                 = Modifier("2")
+	ModItalic                = Modifier("3")
+	ModUnderline             = Modifier("4")
+	ModUnderlineDouble       = Modifier("4:2")
+	ModUnderlineCurly        = Modifier("4:3")
+	ModUnderlineDotted       = Modifier("4:4")
+	ModUnderlineDashed       = Modifier("4:5")
+	ModBlink                 = Modifier("5")
+	ModRapidBlink            = Modifier("6")
+	ModReverse               = Modifier("7")
+	ModConceal               = Modifier("8")
+	ModCrossout              = Modifier("9")
+	ModDoublyUnderlined      = Modifier("21") // legacy double underline, some terminals treat it as bold off
+	ModProportional          = Modifier("26")
+	ModFramed                = Modifier("51")
+	ModEncircled             = Modifier("52")
+	ModOverline              = Modifier("53")
+	ModSuperscript           = Modifier("73")
+	ModSubscript             = Modifier("74")
+
+	// Attribute off codes
+	ModBoldOff               = Modifier("22") // turns off both bold and faint
+	ModItalicOff             = Modifier("23")
+	ModUnderlineOff          = Modifier("24") // turns off all underline styles
+	ModBlinkOff              = Modifier("25")
+	ModProportionalOff       = Modifier("50")
+	ModReverseOff            = Modifier("27")
+	ModConcealOff            = Modifier("28")
+	ModCrossoutOff           = Modifier("29")
+	ModFgDefault             = Modifier("39")
+	ModBgDefault             = Modifier("49")
+	ModFramedOff             = Modifier("54") // turns off both framed and encircled
+	ModOverlineOff           = Modifier("55")
+	ModUnderlineColorDefault = Modifier("59")
+	ModScriptOff             = Modifier("75") // turns off both superscript and subscript
 )
 
+// Font selects alternative font n, 1-9. Font 0 is primary font.
+func Font(n int) Modifier {
+	if n < 0 || n > 9 {
+		return nil
+	}
+	return []byte(strconv.Itoa(10 + n))
+}
+
+// Combine joins modifiers as is, use Merge to resolve conflicting ones
 func Combine(mods ...Modifier) Modifier {
 	// bytes.Join rewritten since it CANNOT ELIDE []Modifier TO [][]byte
 	// return bytes.Join([][]byte(mods), []byte{';'})
@@ -217,19 +265,254 @@ func Combine(mods ...Modifier) Modifier {
 	return b
 }
 
+// output is state shared by all copies of Buffer. It remembers first write
+// error and skips all writes after it.
+type output struct {
+	w   io.Writer
+	n   int64
+	err error
+	// buf is scratch space for building escape sequences without allocations
+	buf []byte
+	// frames are active Styled calls, their modifiers are stored in mods.
+	// Frames are not copied when Styled is nested, so that it doesn't allocate.
+	frames []styleFrame
+	mods   []Modifier
+	// cur is index of frame which style terminal has, -1 if default style
+	cur int
+}
+
+// styleFrame is Styled call, its modifiers are mods[start:end] of output
+type styleFrame struct {
+	start, end int
+	// parent is index of enclosing frame, -1 if none
+	parent int
+}
+
+// maxScratch is capacity of scratch buffer kept after writing long string
+const maxScratch = 4 << 10
+
+func (w *output) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.w.Write(p)
+	return w.written(n, len(p), err)
+}
+
+func (w *output) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if sw, ok := w.w.(io.StringWriter); ok {
+		n, err := sw.WriteString(s)
+		return w.written(n, len(s), err)
+	}
+
+	// copy to scratch buffer to avoid allocating on conversion to []byte
+	w.buf = append(w.buf[:0], s...)
+	n, err := w.w.Write(w.buf)
+	if cap(w.buf) > maxScratch {
+		// don't keep memory of long string for buffer lifetime
+		w.buf = nil
+	}
+	return w.written(n, len(s), err)
+}
+
+// written counts n bytes written out of size and remembers error
+func (w *output) written(n, size int, err error) (int, error) {
+	w.n += int64(n)
+	if err == nil && n < size {
+		err = io.ErrShortWrite
+	}
+	w.err = err
+	return n, err
+}
+
+// writeSGR writes SGR sequence setting modifiers, resetting everything before if reset is set
+func (w *output) writeSGR(reset bool, mods []Modifier) {
+	w.buf = appendSGR(w.buf[:0], reset, mods)
+	w.Write(w.buf) //nolint:errcheck // error is remembered by output
+}
+
+// appendFrame appends reset and modifiers of frame with all enclosing ones to SGR sequence dst
+func (w *output) appendFrame(dst []byte, frame int) []byte {
+	// frame is gone if buffer is used after its Styled call returned
+	if frame == -1 || frame >= len(w.frames) {
+		return append(dst, '0')
+	}
+
+	dst = w.appendFrame(dst, w.frames[frame].parent)
+	for _, mod := range w.mods[w.frames[frame].start:w.frames[frame].end] {
+		dst = append(append(dst, ';'), mod...)
+	}
+	return dst
+}
+
+// enter brings terminal to style of frame, resetting everything and setting modifiers of
+// frame with all enclosing ones, if terminal has style of other frame
+func (w *output) enter(frame int) {
+	if w.cur == frame {
+		return
+	}
+
+	w.buf = append(w.appendFrame(append(w.buf[:0], _csi...), frame), 'm')
+	w.Write(w.buf) //nolint:errcheck // error is remembered by output
+	w.cur = frame
+}
+
+// appendSGR appends SGR sequence setting modifiers to dst, resetting everything before if reset is set
+func appendSGR(dst []byte, reset bool, mods []Modifier) []byte {
+	dst = append(dst, _csi...)
+	if reset {
+		dst = append(dst, '0')
+	}
+	for i, mod := range mods {
+		if i > 0 || reset {
+			dst = append(dst, ';')
+		}
+		dst = append(dst, mod...)
+	}
+	return append(dst, 'm')
+}
+
+// Merge combines modifiers resolving conflicts like Combine, but understanding SGR semantics:
+// repeated attributes are deduplicated, later colors override earlier ones, off codes cancel
+// earlier on codes. Result is shortest equivalent modifier. If some modifier can't be parsed,
+// modifiers are combined as is.
+func Merge(mods ...Modifier) Modifier {
+	var res Style
+	for _, mod := range mods {
+		s, err := ParseSGR(mod)
+		if err != nil {
+			return Combine(mods...)
+		}
+		res = res.Merge(s)
+	}
+	return res.Modifier()
+}
+
+// Off returns modifier turning off everything given modifier turns on, e.g. ModBoldOff for ModBold
+// or ModFgDefault for FgRed. Returns nil if modifier turns on nothing.
+func Off(mod Modifier) Modifier {
+	var p pen
+	p.apply(mod)
+
+	res := []string{}
+	for i, attr := range attrCodes {
+		if p.attrs&(1<<i) != 0 && !slices.Contains(res, attr.off) {
+			res = append(res, attr.off)
+		}
+	}
+	for _, c := range [...]struct{ color, off string }{{p.fg, "39"}, {p.bg, "49"}, {p.ul, "59"}} {
+		if c.color != "" {
+			res = append(res, c.off)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+	return Modifier(strings.Join(res, ";"))
+}
+
+// Buffer writes styled text to writer. Buffer is cheap to copy, all copies, including
+// buffers passed to callbacks, share write state: error, written bytes count and active
+// styles. So, like bufio.Writer, Buffer and buffers derived from it must not be used
+// concurrently, even if underlying writer is safe for concurrent use.
 type Buffer struct {
-	w io.Writer
+	w       *output
+	profile Profile
+	// frame is index of innermost Styled call frame of this buffer, -1 if none
+	frame int
+	// pen is current terminal style, nil if optimizing mode is disabled
+	pen *pen
+	// want is style terminal should have when writing, used in optimizing mode
+	want pen
+}
+
+// Option configures Buffer created by New
+type Option func(*Buffer)
+
+// WithProfile sets color profile, colors are downsampled to ones supported by it
+func WithProfile(p Profile) Option {
+	return func(b *Buffer) {
+		b.profile = p
+	}
+}
+
+// WithOptimize enables optimizing mode. In this mode buffer tracks terminal style
+// and writes only difference between current and needed styles. Reset is deferred
+// until something is written without style, so Reset must be called after all.
+func WithOptimize() Option {
+	return func(b *Buffer) {
+		b.pen = &pen{}
+	}
 }
 
-func New(out io.Writer) Buffer {
-	return Buffer{out}
+// New creates Buffer writing to out. Returned buffer must not be used concurrently,
+// create buffer for each goroutine instead.
+func New(out io.Writer, opts ...Option) Buffer {
+	b := Buffer{w: &output{w: out, cur: -1}, profile: TrueColor, frame: -1}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
 }
 
 func (b Buffer) write(bs ...byte) Buffer {
-	b.w.Write(bs) //nolint:errcheck // fuck you
+	b.sync()
+	b.w.Write(bs) //nolint:errcheck // error is remembered by output
+	return b
+}
+
+// writeByte writes single byte, unlike write it doesn't allocate
+func (b Buffer) writeByte(c byte) Buffer {
+	b.sync()
+	b.w.buf = append(b.w.buf[:0], c)
+	b.w.Write(b.w.buf) //nolint:errcheck // error is remembered by output
 	return b
 }
 
+// Err returns first error occurred while writing, all writes after it are skipped
+func (b Buffer) Err() error {
+	return b.w.err
+}
+
+// N returns number of bytes written
+func (b Buffer) N() int64 {
+	return b.w.n
+}
+
+var (
+	_ io.Writer       = Buffer{}
+	_ io.StringWriter = Buffer{}
+	_ io.ReaderFrom   = Buffer{}
+)
+
+// Write implements io.Writer, so buffer can be passed to code writing text, e.g. fmt.Fprintf.
+// Bytes are written using active style. Error is remembered by buffer, after it all writes fail.
+func (b Buffer) Write(p []byte) (int, error) {
+	b.sync()
+	return b.w.Write(p)
+}
+
+// WriteString implements io.StringWriter, same as Write
+func (b Buffer) WriteString(s string) (int, error) {
+	b.sync()
+	return b.w.WriteString(s)
+}
+
+// ReadFrom implements io.ReaderFrom, copying everything from reader to buffer same as Write
+func (b Buffer) ReadFrom(r io.Reader) (int64, error) {
+	b.sync()
+	if b.w.err != nil {
+		return 0, b.w.err
+	}
+	return io.Copy(b.w, r)
+}
+
 // Bytes writes bytes to buffer
 func (b Buffer) Bytes(bs ...byte) Buffer {
 	return b.write(bs...)
@@ -242,59 +525,222 @@ func (b Buffer) RepeatByte(c byte, n int) Buffer {
 
 // Printf writes formatted data to buffer
 func (b Buffer) Printf(format string, args ...any) Buffer {
-	fmt.Fprintf(b.w, format, args...)
+	b.sync()
+	fmt.Fprintf(b.w, format, args...) //nolint:errcheck // error is remembered by output
 	return b
 }
 
-func (b Buffer) writeMods(mods ...Modifier) {
-	b.write(_csi...)
-	for i, mod := range mods {
-		if i > 0 {
-			b.write(';')
+// Styled write things in callback using modifiers. Styled can be nested,
+// style of enclosing Styled is restored after inner one ends.
+func (b Buffer) Styled(f func(Buffer), mods ...Modifier) Buffer {
+	start := len(b.w.mods)
+	for _, mod := range mods {
+		if mod = b.profile.Convert(mod); len(mod) > 0 {
+			b.w.mods = append(b.w.mods, mod)
 		}
-		b.write(mod...)
 	}
-	b.write('m')
+	return b.styled(f, start, nil)
 }
 
-// Styled write things in callback using modifiers. Don't use Styled inside Styled.
-func (b Buffer) Styled(f func(Buffer), mods ...Modifier) Buffer {
-	totalLen := 0
-	for _, mod := range mods {
-		totalLen += len(mod)
+// styled writes things in callback using modifiers pushed to output after start.
+// If prefix is not nil, it is written to set modifiers.
+func (b Buffer) styled(f func(Buffer), start int, prefix []byte) Buffer {
+	if len(b.w.mods) == start {
+		f(b)
+		return b
 	}
 
-	if totalLen == 0 {
-		f(b)
+	// new frame is pushed on top of all frames, not after buffer frame, since
+	// enclosing buffers can be used inside callback of nested Styled
+	inner := b
+	inner.frame = len(b.w.frames)
+	b.w.frames = append(b.w.frames, styleFrame{start: start, end: len(b.w.mods), parent: b.frame})
+	defer func() {
+		b.w.frames = b.w.frames[:inner.frame]
+		b.w.mods = b.w.mods[:start]
+	}()
+
+	if b.pen != nil {
+		// style is written lazily on next write
+		for _, mod := range b.w.mods[start:] {
+			inner.want.apply(mod)
+		}
+		f(inner)
 		return b
 	}
 
-	b.writeMods(mods...)
-	f(b)
-	b.writeMods(ModReset)
+	switch {
+	case b.w.cur != b.frame:
+		// enclosing buffer changed style, so whole style is written
+		b.w.enter(inner.frame)
+	case prefix != nil:
+		b.w.Write(prefix) //nolint:errcheck // error is remembered by output
+	default:
+		b.w.writeSGR(false, b.w.mods[start:])
+	}
+	b.w.cur = inner.frame
+	f(inner)
+	// reset everything, then restore enclosing style
+	b.w.enter(b.frame)
 	return b
 }
 
+// Compiled is set of modifiers converted for buffer profile and compiled into escape
+// sequence once, so that it can be written repeatedly without processing modifiers
+type Compiled struct {
+	mods   []Modifier
+	prefix []byte
+}
+
+// Compile converts modifiers for buffer profile and compiles them into escape sequence.
+// Result must be used only with buffers having same profile.
+func (b Buffer) Compile(mods ...Modifier) Compiled {
+	var c Compiled
+	for _, mod := range mods {
+		if mod = b.profile.Convert(mod); len(mod) > 0 {
+			c.mods = append(c.mods, mod)
+		}
+	}
+	if len(c.mods) > 0 {
+		c.prefix = appendSGR(nil, false, c.mods)
+	}
+	return c
+}
+
+// StyledCompiled writes things in callback using compiled modifiers
+func (b Buffer) StyledCompiled(f func(Buffer), c Compiled) Buffer {
+	start := len(b.w.mods)
+	b.w.mods = append(b.w.mods, c.mods...)
+	return b.styled(f, start, c.prefix)
+}
+
+// StringCompiled writes string to buffer with compiled modifiers
+func (b Buffer) StringCompiled(s string, c Compiled) Buffer {
+	return b.StyledCompiled(func(b Buffer) {
+		b.sync()
+		b.w.WriteString(s) //nolint:errcheck // error is remembered by output
+	}, c)
+}
+
+// StyledWith writes things in callback using style
+func (b Buffer) StyledWith(f func(Buffer), s Style) Buffer {
+	return b.Styled(f, s.Modifier())
+}
+
 // String writes string to buffer with given modifiers
 func (b Buffer) String(s string, mods ...Modifier) Buffer {
 	return b.Styled(func(b Buffer) {
-		io.WriteString(b.w, s) //nolint:errcheck // fuck you
+		b.sync()
+		b.w.WriteString(s) //nolint:errcheck // error is remembered by output
 	}, mods...)
 }
 
+// Gradient writes string coloring each grapheme with foreground color interpolated between color stops
+func (b Buffer) Gradient(s string, stops ...Color) Buffer {
+	return b.gradient(s, stops, Color.Fg)
+}
+
+// BgGradient writes string coloring each grapheme with background color interpolated between color stops
+func (b Buffer) BgGradient(s string, stops ...Color) Buffer {
+	return b.gradient(s, stops, Color.Bg)
+}
+
+func (b Buffer) gradient(s string, stops []Color, mod func(Color) Modifier) Buffer {
+	gs := graphemes(s)
+	for i, g := range gs {
+		b.String(g, mod(gradientAt(stops, float64(i)/float64(max(len(gs)-1, 1)))))
+	}
+	return b
+}
+
+// Reset brings terminal to default style. Needed only in optimizing mode, where
+// reset is deferred, must be called after everything is written.
+func (b Buffer) Reset() Buffer {
+	if b.pen == nil {
+		return b
+	}
+
+	b.want = pen{}
+	b.sync()
+	return b
+}
+
 // NL writes newline
 func (b Buffer) NL() Buffer {
-	return b.write('\n')
+	return b.writeByte('\n')
 }
 
 // TAB writes tab
 func (b Buffer) TAB() Buffer {
-	return b.write('\t')
+	return b.writeByte('\t')
 }
 
 // SPC writes space
 func (b Buffer) SPC() Buffer {
-	return b.write(' ')
+	return b.writeByte(' ')
+}
+
+// Alignment is horizontal alignment of text in Align
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+	AlignCenter
+)
+
+// Align writes callback aligned in width cells, padding it with spaces.
+// Written text is measured by Width, text wider than width is written as is.
+func (b Buffer) Align(width int, align Alignment, f func(Buffer)) Buffer {
+	return b.AlignFill(width, align, " ", f)
+}
+
+// AlignFill is like Align, but pads text with fill string written with modifiers.
+// If fill doesn't fit remaining space, it is padded with spaces.
+func (b Buffer) AlignFill(width int, align Alignment, fill string, f func(Buffer), mods ...Modifier) Buffer {
+	// render callback separately to measure it
+	var bb bytes.Buffer
+	inner := b
+	inner.w = &output{w: &bb, frames: slices.Clone(b.w.frames), mods: slices.Clone(b.w.mods), cur: b.frame}
+	if b.pen != nil {
+		// callback is rendered starting from buffer style
+		p := b.want
+		inner.pen = &p
+	}
+	f(inner)
+
+	pad := max(width-Width(bb.String()), 0)
+	left := 0
+	switch align {
+	case AlignRight:
+		left = pad
+	case AlignCenter:
+		left = pad / 2
+	}
+
+	b.fill(left, fill, mods)
+	// bring terminal to buffer style, which rendered callback starts from
+	b.sync()
+	b.w.Write(bb.Bytes()) //nolint:errcheck // error is remembered by output
+	b.w.cur = inner.w.cur
+	if b.pen != nil {
+		*b.pen = *inner.pen
+	}
+	b.fill(pad-left, fill, mods)
+	return b
+}
+
+// fill writes n cells of fill string
+func (b Buffer) fill(n int, fill string, mods []Modifier) {
+	if n == 0 {
+		return
+	}
+
+	fillWidth := Width(fill)
+	if fillWidth == 0 {
+		fill, fillWidth = " ", 1
+	}
+	b.String(strings.Repeat(fill, n/fillWidth)+strings.Repeat(" ", n%fillWidth), mods...)
 }
 
 // InBytePair writes callback inside given byte pair, e.g. parentheses or quotes
@@ -315,16 +761,24 @@ func (b Buffer) Iter(seq func(yield func(func(Buffer)) bool) bool) Buffer {
 	return b
 }
 
-// Hyperlink writes hyperlink using OSC8
+// Hyperlink writes hyperlink using OSC8. Ascii profile writes only name.
 func (b Buffer) Hyperlink(link, name string) Buffer {
-	return b.
+	b.setLink(link)
+	b.String(name)
+	b.setLink("")
+	return b
+}
+
+// setLink starts hyperlink using OSC8, empty link ends it. Nothing is written for Ascii profile.
+func (b Buffer) setLink(link string) {
+	if b.profile == Ascii {
+		return
+	}
+
+	b.
 		write(_osc...).
 		String("8;;").
 		String(link).
-		write(_stringTerminator...).
-		String(name).
-		write(_osc...).
-		String("8;;").
 		write(_stringTerminator...)
 }
 
@@ -342,14 +796,16 @@ func (b Buffer) Notify(title, body string) Buffer {
 // Copy text to clipboard using OSC 52 escape sequence
 func (b Buffer) Copy(str string) Buffer {
 	s := osc52.New(str)
-	s.WriteTo(b.w) //nolint:errcheck // fuck you
+	b.sync()
+	s.WriteTo(b.w) //nolint:errcheck // error is remembered by output
 	return b
 }
 
 // CopyPrimary text to primary clipboard (X11) using OSC 52 escape sequence
 func (b Buffer) CopyPrimary(str string) Buffer {
 	s := osc52.New(str).Primary()
-	s.WriteTo(b.w) //nolint:errcheck // fuck you
+	b.sync()
+	s.WriteTo(b.w) //nolint:errcheck // error is remembered by output
 	return b
 }
 
@@ -1,6 +1,11 @@
 package scuf
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -147,3 +152,626 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestStyledNested(t *testing.T) {
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"string inside styled": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.String("a ").String("red", FgRed).String(" b")
+				}, ModBold)
+			},
+			"\x1b[1ma \x1b[31mred\x1b[0;1m b\x1b[0m",
+		},
+		"deep nesting": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.Styled(func(b Buffer) {
+						b.String("x", ModItalic).String("y")
+					}, BgBlue).String("z")
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[44m\x1b[3mx\x1b[0;1;44my\x1b[0;1mz\x1b[0m",
+		},
+		"unstyled inside styled": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.String("a").String("b", nil)
+				}, FgRed)
+			},
+			"\x1b[31mab\x1b[0m",
+		},
+		"enclosing buffer used inside": {
+			func(outer Buffer) {
+				outer.Styled(func(inner Buffer) {
+					outer.String("x", FgRed)
+					inner.String("y", ModItalic).String("z")
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[0;31mx\x1b[0m\x1b[0;1;3my\x1b[0;1mz\x1b[0m",
+		},
+		"enclosing nested buffer used inside": {
+			func(b Buffer) {
+				b.Styled(func(outer Buffer) {
+					outer.Styled(func(inner Buffer) {
+						outer.String("x", FgRed)
+						inner.String("y")
+					}, ModItalic).String("z")
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[3m\x1b[0;1;31mx\x1b[0;1m\x1b[0;1;3my\x1b[0;1mz\x1b[0m",
+		},
+		"siblings": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.String("x", FgRed).String("y", FgBlue).String("z")
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[31mx\x1b[0;1m\x1b[34my\x1b[0;1mz\x1b[0m",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewString(test.f))
+		})
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"single": {
+			func(b Buffer) {
+				b.String("a", FgRed).Reset()
+			},
+			"\x1b[31ma\x1b[0m",
+		},
+		"same style": {
+			func(b Buffer) {
+				b.String("a", FgRed).String("b", FgRed).Reset()
+			},
+			"\x1b[31mab\x1b[0m",
+		},
+		"change color": {
+			func(b Buffer) {
+				b.String("a", ModBold, FgRed).String("b", ModBold, FgBlue).Reset()
+			},
+			"\x1b[1;31ma\x1b[34mb\x1b[0m",
+		},
+		"turn off bold": {
+			func(b Buffer) {
+				b.String("a", ModBold, FgRGB(1, 2, 3)).String("b", FgRGB(1, 2, 3)).Reset()
+			},
+			"\x1b[1;38;2;1;2;3ma\x1b[22mb\x1b[0m",
+		},
+		"turn off faint keeps bold": {
+			func(b Buffer) {
+				b.String("a", ModBold, ModFaint, BgRGB(1, 2, 3)).String("b", ModBold, BgRGB(1, 2, 3)).Reset()
+			},
+			"\x1b[1;2;48;2;1;2;3ma\x1b[22;1mb\x1b[0m",
+		},
+		"reset before unstyled": {
+			func(b Buffer) {
+				b.String("a", BgRed).NL().String("b", BgRed).SPC().Reset()
+			},
+			"\x1b[41ma\x1b[0m\n\x1b[41mb\x1b[0m ",
+		},
+		"nested": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.String("a").String("b", FgRed).String("c")
+				}, ModBold).Reset()
+			},
+			"\x1b[1ma\x1b[31mb\x1b[39mc\x1b[0m",
+		},
+		"printf": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.Printf("%d", 42)
+				}, ModItalic).Printf("%d", 0)
+			},
+			"\x1b[3m42\x1b[0m0",
+		},
+		"underline styles": {
+			func(b Buffer) {
+				b.String("a", ModUnderline, UnderlineANSI(1)).String("b", ModUnderlineCurly, UnderlineANSI(1)).String("c", ModUnderlineCurly).Reset()
+			},
+			"\x1b[4;58;5;1ma\x1b[4:3mb\x1b[59mc\x1b[0m",
+		},
+		"conceal": {
+			func(b Buffer) {
+				b.String("a", ModConceal, FgRed).String("b", FgRed).Reset()
+			},
+			"\x1b[8;31ma\x1b[28mb\x1b[0m",
+		},
+		"blink replaced by rapid blink": {
+			func(b Buffer) {
+				b.String("a", ModBlink).String("b", ModRapidBlink).Reset()
+			},
+			"\x1b[5ma\x1b[6mb\x1b[0m",
+		},
+		"unknown attribute": {
+			func(b Buffer) {
+				b.String("a", Modifier("20"), FgRed).String("b", FgRed).Reset()
+			},
+			"\x1b[31;20ma\x1b[0;31mb\x1b[0m",
+		},
+		"nothing written": {
+			func(b Buffer) {
+				b.Reset()
+			},
+			"",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var bb bytes.Buffer
+			test.f(New(&bb, WithOptimize()))
+			assert.Equal(t, test.expected, bb.String())
+		})
+	}
+}
+
+// limitedWriter fails after writing limit bytes
+type limitedWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		n, _ := w.Buffer.Write(p[:w.limit-w.Len()])
+		return n, io.ErrClosedPipe
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *limitedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func TestErr(t *testing.T) {
+	w := &limitedWriter{limit: 10}
+	b := New(w)
+	assert.NoError(t, b.String("hello").Err())
+	assert.Equal(t, int64(5), b.N())
+
+	b.String("world", FgRed).Printf("%d", 42).String("!")
+	assert.ErrorIs(t, b.Err(), io.ErrClosedPipe)
+	assert.Equal(t, int64(10), b.N())
+	assert.Equal(t, "hello\x1b[31m", w.String())
+}
+
+func TestErrShortWrite(t *testing.T) {
+	b := New(writerFunc(func(p []byte) (int, error) {
+		return len(p) / 2, nil
+	}))
+	assert.ErrorIs(t, b.String("hello").NL().Err(), io.ErrShortWrite)
+	assert.Equal(t, int64(2), b.N())
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestLongString(t *testing.T) {
+	s := strings.Repeat("x", 1<<20)
+	for name, w := range map[string]io.Writer{
+		"string writer": &bytes.Buffer{},
+		"writer":        writerFunc(func(p []byte) (int, error) { return len(p), nil }),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := New(w)
+			b.String(s)
+			assert.NoError(t, b.Err())
+			assert.Equal(t, int64(len(s)), b.N())
+			assert.LessOrEqual(t, cap(b.w.buf), maxScratch)
+		})
+	}
+}
+
+func TestGradient(t *testing.T) {
+	red, blue := RGBColor(255, 0, 0), RGBColor(0, 0, 255)
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"no stops": {
+			func(b Buffer) { b.Gradient("ab") },
+			"ab",
+		},
+		"single stop": {
+			func(b Buffer) { b.Gradient("ab", red) },
+			"\x1b[38;2;255;0;0ma\x1b[0m\x1b[38;2;255;0;0mb\x1b[0m",
+		},
+		"two stops": {
+			func(b Buffer) { b.Gradient("abc", red, blue) },
+			"\x1b[38;2;255;0;0ma\x1b[0m\x1b[38;2;140;83;162mb\x1b[0m\x1b[38;2;0;0;255mc\x1b[0m",
+		},
+		"background": {
+			func(b Buffer) { b.BgGradient("ab", red, blue) },
+			"\x1b[48;2;255;0;0ma\x1b[0m\x1b[48;2;0;0;255mb\x1b[0m",
+		},
+		"graphemes": {
+			func(b Buffer) { b.Gradient("é🇯🇵", red, blue) },
+			"\x1b[38;2;255;0;0mé\x1b[0m\x1b[38;2;0;0;255m🇯🇵\x1b[0m",
+		},
+		"three stops": {
+			func(b Buffer) { b.Gradient("abc", red, blue, red) },
+			"\x1b[38;2;255;0;0ma\x1b[0m\x1b[38;2;0;0;255mb\x1b[0m\x1b[38;2;255;0;0mc\x1b[0m",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewString(test.f))
+		})
+	}
+}
+
+func TestGradientProfile(t *testing.T) {
+	var bb bytes.Buffer
+	New(&bb, WithProfile(ANSI16)).Gradient("ab", RGBColor(255, 0, 0), RGBColor(0, 0, 255))
+	assert.Equal(t, "\x1b[91ma\x1b[0m\x1b[94mb\x1b[0m", bb.String())
+}
+
+func TestOff(t *testing.T) {
+	for name, test := range map[string]struct {
+		mod      Modifier
+		expected Modifier
+	}{
+		"bold":              {ModBold, ModBoldOff},
+		"faint":             {ModFaint, ModBoldOff},
+		"italic":            {ModItalic, ModItalicOff},
+		"underline":         {ModUnderline, ModUnderlineOff},
+		"curly underline":   {ModUnderlineCurly, ModUnderlineOff},
+		"double underline":  {ModDoublyUnderlined, ModUnderlineOff},
+		"blink":             {ModBlink, ModBlinkOff},
+		"rapid blink":       {ModRapidBlink, ModBlinkOff},
+		"reverse":           {ModReverse, ModReverseOff},
+		"conceal":           {ModConceal, ModConcealOff},
+		"crossout":          {ModCrossout, ModCrossoutOff},
+		"font":              {Font(3), Font(0)},
+		"proportional":      {ModProportional, ModProportionalOff},
+		"framed":            {ModFramed, ModFramedOff},
+		"encircled":         {ModEncircled, ModFramedOff},
+		"overline":          {ModOverline, ModOverlineOff},
+		"superscript":       {ModSuperscript, ModScriptOff},
+		"subscript":         {ModSubscript, ModScriptOff},
+		"fg":                {FgRed, ModFgDefault},
+		"fg bright":         {FgHiRed, ModFgDefault},
+		"fg rgb":            {FgRGB(1, 2, 3), ModFgDefault},
+		"bg":                {BgANSI(100), ModBgDefault},
+		"bg bright":         {BgHiBlue, ModBgDefault},
+		"underline color":   {UnderlineRGB(1, 2, 3), ModUnderlineColorDefault},
+		"combined":          {Combine(ModBold, FgRed, ModFaint, BgBlue, ModItalic), Modifier("22;23;39;49")},
+		"reset":             {ModReset, nil},
+		"off code":          {ModBoldOff, nil},
+		"nil":               {nil, nil},
+		"unknown attribute": {Modifier("20"), nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Off(test.mod))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for name, test := range map[string]struct {
+		mods     []Modifier
+		expected Modifier
+	}{
+		"empty":             {nil, nil},
+		"nil modifiers":     {[]Modifier{nil, nil}, nil},
+		"single":            {[]Modifier{FgRed}, FgRed},
+		"conflicting fg":    {[]Modifier{FgRed, FgBlue, ModBold, ModBold}, Modifier("1;34")},
+		"conflicting bg":    {[]Modifier{BgRGB(1, 2, 3), BgANSI(100), BgHiBlue}, BgHiBlue},
+		"underline color":   {[]Modifier{UnderlineANSI(1), UnderlineRGB(1, 2, 3)}, UnderlineRGB(1, 2, 3)},
+		"off cancels on":    {[]Modifier{ModBold, ModItalic, ModBoldOff}, Modifier("22;3")},
+		"on after off":      {[]Modifier{ModItalicOff, ModItalic}, ModItalic},
+		"default color":     {[]Modifier{FgRed, ModFgDefault}, ModFgDefault},
+		"underline styles":  {[]Modifier{ModUnderline, ModUnderlineCurly}, ModUnderlineCurly},
+		"reset":             {[]Modifier{ModBold, FgRed, ModReset, ModItalic}, Modifier("0;3")},
+		"combined inputs":   {[]Modifier{Combine(ModBold, FgRed), Combine(FgBlue, ModBold)}, Modifier("1;34")},
+		"ansi256 shortened": {[]Modifier{FgANSI(300), Modifier("38;5;1")}, FgRed},
+		"unparseable":       {[]Modifier{Modifier("20"), FgRed, FgBlue}, Modifier("20;31;34")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Merge(test.mods...))
+		})
+	}
+}
+
+func TestCompiled(t *testing.T) {
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"simple": {
+			func(b Buffer) {
+				c := b.Compile(ModBold, FgRed)
+				b.StringCompiled("a", c).StringCompiled("b", c)
+			},
+			"\x1b[1;31ma\x1b[0m\x1b[1;31mb\x1b[0m",
+		},
+		"nested": {
+			func(b Buffer) {
+				c := b.Compile(FgRed)
+				b.Styled(func(b Buffer) {
+					b.StringCompiled("a", c).String("b")
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[31ma\x1b[0;1mb\x1b[0m",
+		},
+		"empty": {
+			func(b Buffer) {
+				b.StringCompiled("a", b.Compile(nil))
+			},
+			"a",
+		},
+		"styled": {
+			func(b Buffer) {
+				b.StyledCompiled(func(b Buffer) {
+					b.String("a").String("b", ModItalic)
+				}, b.Compile(BgBlue))
+			},
+			"\x1b[44ma\x1b[3mb\x1b[0;44m\x1b[0m",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewString(test.f))
+		})
+	}
+
+	var bb bytes.Buffer
+	b := New(&bb, WithProfile(ANSI16))
+	b.StringCompiled("a", b.Compile(FgRGB(255, 0, 0)))
+	assert.Equal(t, "\x1b[91ma\x1b[0m", bb.String())
+}
+
+func TestInternedModifiers(t *testing.T) {
+	assert.Equal(t, Modifier("38;5;200"), FgANSI(200))
+	assert.Equal(t, Modifier("107"), BgANSI(15))
+	assert.Equal(t, Modifier("58;5;3"), UnderlineANSI(3))
+	assert.Equal(t, Modifier("38;2;0;128;255"), FgRGB(0, 128, 255))
+
+	// appending to interned modifier must not change it
+	_ = append(FgANSI(200), ";1"...)
+	assert.Equal(t, Modifier("38;5;200"), FgANSI(200))
+}
+
+func BenchmarkString(b *testing.B) {
+	buf := New(io.Discard)
+	mods := []Modifier{ModBold, FgANSI(200), BgRGB(1, 2, 3)}
+	assertNoAllocs(b, func() {
+		buf.String("hello", mods...)
+	})
+}
+
+func BenchmarkStringNested(b *testing.B) {
+	buf := New(io.Discard)
+	assertNoAllocs(b, func() {
+		buf.Styled(func(buf Buffer) {
+			buf.String("hello", FgRed).String(" world")
+		}, ModBold)
+	})
+}
+
+func BenchmarkStringCompiled(b *testing.B) {
+	buf := New(io.Discard)
+	c := buf.Compile(ModBold, FgANSI(200), BgRGB(1, 2, 3))
+	assertNoAllocs(b, func() {
+		buf.StringCompiled("hello", c)
+	})
+}
+
+// sinkModifier keeps benchmarked results, so that compiler doesn't remove calls
+var sinkModifier Modifier
+
+func BenchmarkFgRGB(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sinkModifier = FgRGB(1, 2, 3)
+	}
+}
+
+// assertNoAllocs runs f as benchmark, failing if f allocates
+func assertNoAllocs(b *testing.B, f func()) {
+	b.Helper()
+	if allocs := testing.AllocsPerRun(100, f); allocs != 0 {
+		b.Fatalf("expected no allocations, got %v", allocs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f()
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"fprintf": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					fmt.Fprintf(b, "%d-%s", 42, "x")
+				}, FgRed)
+			},
+			"\x1b[31m42-x\x1b[0m",
+		},
+		"write string": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					io.WriteString(b, "hello")
+				}, ModBold)
+			},
+			"\x1b[1mhello\x1b[0m",
+		},
+		"copy": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					io.Copy(b, strings.NewReader("copied"))
+				}, ModItalic)
+			},
+			"\x1b[3mcopied\x1b[0m",
+		},
+		"json": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					json.NewEncoder(b).Encode(map[string]int{"a": 1})
+				}, FgGreen)
+			},
+			"\x1b[32m{\"a\":1}\n\x1b[0m",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewString(test.f))
+		})
+	}
+}
+
+func TestWriterOptimize(t *testing.T) {
+	var bb bytes.Buffer
+	b := New(&bb, WithOptimize())
+	b.Styled(func(b Buffer) {
+		fmt.Fprint(b, "a")
+		b.ReadFrom(strings.NewReader("b"))
+	}, FgRed)
+	b.Reset()
+	assert.Equal(t, "\x1b[31mab\x1b[0m", bb.String())
+}
+
+func TestWriterErr(t *testing.T) {
+	w := &limitedWriter{limit: 3}
+	b := New(w)
+
+	n, err := b.Write([]byte("hello"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Equal(t, 3, n)
+
+	n, err = b.WriteString("world")
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Equal(t, 0, n)
+
+	m, err := b.ReadFrom(strings.NewReader("!"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Equal(t, int64(0), m)
+	assert.Equal(t, "hel", w.String())
+}
+
+func TestAlign(t *testing.T) {
+	for name, test := range map[string]struct {
+		f        func(Buffer)
+		expected string
+	}{
+		"left": {
+			func(b Buffer) {
+				b.Align(6, AlignLeft, func(b Buffer) { b.String("ab") }).String("|")
+			},
+			"ab    |",
+		},
+		"right": {
+			func(b Buffer) {
+				b.Align(6, AlignRight, func(b Buffer) { b.String("ab") }).String("|")
+			},
+			"    ab|",
+		},
+		"center": {
+			func(b Buffer) {
+				b.Align(7, AlignCenter, func(b Buffer) { b.String("ab") }).String("|")
+			},
+			"  ab   |",
+		},
+		"styled text": {
+			func(b Buffer) {
+				b.Align(4, AlignRight, func(b Buffer) { b.String("ab", FgRed) })
+			},
+			"  \x1b[31mab\x1b[0m",
+		},
+		"wide text": {
+			func(b Buffer) {
+				b.Align(5, AlignLeft, func(b Buffer) { b.String("日本") }).String("|")
+			},
+			"日本 |",
+		},
+		"too wide": {
+			func(b Buffer) {
+				b.Align(2, AlignCenter, func(b Buffer) { b.String("abcd") })
+			},
+			"abcd",
+		},
+		"inside styled": {
+			func(b Buffer) {
+				b.Styled(func(b Buffer) {
+					b.Align(4, AlignLeft, func(b Buffer) { b.String("a", FgRed) })
+				}, ModBold)
+			},
+			"\x1b[1m\x1b[31ma\x1b[0;1m   \x1b[0m",
+		},
+		"fill": {
+			func(b Buffer) {
+				b.AlignFill(6, AlignCenter, "-", func(b Buffer) { b.String("ab") })
+			},
+			"--ab--",
+		},
+		"styled fill": {
+			func(b Buffer) {
+				b.AlignFill(4, AlignRight, ".", func(b Buffer) { b.String("ab") }, FgBlack)
+			},
+			"\x1b[30m..\x1b[0mab",
+		},
+		"wide fill": {
+			func(b Buffer) {
+				b.AlignFill(5, AlignLeft, "日", func(b Buffer) { b.String("ab") })
+			},
+			"ab日 ",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewString(test.f))
+		})
+	}
+}
+
+func TestAlignOptimize(t *testing.T) {
+	var bb bytes.Buffer
+	b := New(&bb, WithOptimize())
+	b.Styled(func(b Buffer) {
+		b.Align(4, AlignRight, func(b Buffer) {
+			b.String("a", ModItalic)
+		}).String("b")
+	}, ModBold)
+	b.Reset()
+	assert.Equal(t, "\x1b[1m   \x1b[3ma\x1b[23mb\x1b[0m", bb.String())
+
+	for name, test := range map[string]struct {
+		align    Alignment
+		expected string
+	}{
+		"right":  {AlignRight, "\x1b[31m....\x1b[0mab\n"},
+		"center": {AlignCenter, "\x1b[31m..\x1b[0mab\x1b[31m..\x1b[0m\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var bb bytes.Buffer
+			New(&bb, WithOptimize()).
+				AlignFill(6, test.align, ".", func(b Buffer) { b.String("ab") }, FgRed).
+				NL().
+				Reset()
+			assert.Equal(t, test.expected, bb.String())
+		})
+	}
+
+	bb.Reset()
+	b = New(&bb, WithOptimize())
+	b.Styled(func(b Buffer) {
+		b.AlignFill(4, AlignCenter, "-", func(b Buffer) { b.String("a", FgRed) }, FgBlue).String("b")
+	}, ModBold)
+	b.Reset()
+	assert.Equal(t, "\x1b[1;34m-\x1b[39m\x1b[31ma\x1b[34m--\x1b[39mb\x1b[0m", bb.String())
+}
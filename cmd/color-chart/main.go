@@ -7,15 +7,13 @@ import (
 	"github.com/rprtr258/scuf"
 )
 
-func fgStep(i, step int) scuf.Modifier {
-	if i >= step {
-		return scuf.FgBlack
-	}
-	return scuf.FgWhite
+// fg picks readable foreground color on given background
+func fg(bg scuf.Color) scuf.Modifier {
+	return scuf.ReadableOn(bg, scuf.ANSIColor(0), scuf.ANSIColor(15)).Fg()
 }
 
 func main() {
-	b := scuf.New(os.Stdout)
+	b := scuf.New(os.Stdout, scuf.WithOptimize())
 
 	b.String("Basic ANSI colors", scuf.ModBold).NL()
 	for i := 0; i < 16; i++ {
@@ -23,10 +21,10 @@ func main() {
 			b.NL()
 		}
 
-		bg := scuf.BgANSI(i)
+		bg := scuf.ANSIColor(i)
 		b.Styled(func(b scuf.Buffer) {
-			b.Printf(" %2d %s ", i, scuf.ToHex(bg))
-		}, fgStep(i, 5), bg)
+			b.Printf(" %2d %s ", i, bg.Hex())
+		}, fg(bg), bg.Bg())
 	}
 	b.NL().NL()
 
@@ -36,10 +34,10 @@ func main() {
 			b.NL()
 		}
 
-		bg := scuf.BgANSI(i)
+		bg := scuf.ANSIColor(i)
 		b.Styled(func(b scuf.Buffer) {
-			b.Printf(" %3d %s ", i, scuf.ToHex(bg))
-		}, fgStep(i, 28), bg)
+			b.Printf(" %3d %s ", i, bg.Hex())
+		}, fg(bg), bg.Bg())
 	}
 	b.NL().NL()
 
@@ -49,10 +47,10 @@ func main() {
 			b.NL()
 		}
 
-		bg := scuf.BgANSI(i)
+		bg := scuf.ANSIColor(i)
 		b.Styled(func(b scuf.Buffer) {
-			b.Printf(" %3d %s ", i, scuf.ToHex(bg))
-		}, fgStep(i, 244), bg)
+			b.Printf(" %3d %s ", i, bg.Hex())
+		}, fg(bg), bg.Bg())
 	}
-	b.NL().NL()
+	b.NL().NL().Reset()
 }
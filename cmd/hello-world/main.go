@@ -7,7 +7,7 @@ import (
 )
 
 func main() {
-	out := scuf.New(os.Stdout)
+	out := scuf.New(os.Stdout, scuf.WithProfile(scuf.DetectProfile(os.Stdout)))
 	out.TAB().
 		String("bold", scuf.ModBold).
 		SPC().String("faint", scuf.ModFaint).
@@ -18,6 +18,11 @@ func main() {
 		SPC().String("reverse", scuf.ModReverse).
 		SPC().String("blink", scuf.ModBlink).
 		NL().TAB().
+		String("double", scuf.ModUnderlineDouble).
+		SPC().String("curly", scuf.ModUnderlineCurly, scuf.UnderlineRGB(scuf.MustParseHexRGB("#E88388"))).
+		SPC().String("dotted", scuf.ModUnderlineDotted).
+		SPC().String("dashed", scuf.ModUnderlineDashed).
+		NL().TAB().
 		String("red", scuf.FgRGB(scuf.MustParseHexRGB("#E88388"))).
 		SPC().String("green", scuf.FgRGB(scuf.MustParseHexRGB("#A8CC8C"))).
 		SPC().String("yellow", scuf.FgRGB(scuf.MustParseHexRGB("#DBAB79"))).
@@ -33,6 +38,8 @@ func main() {
 		SPC().String("magenta", scuf.FgBlack, scuf.BgRGB(scuf.MustParseHexRGB("#D290E4"))).
 		SPC().String("cyan", scuf.FgBlack, scuf.BgRGB(scuf.MustParseHexRGB("#66C2CD"))).
 		SPC().String("gray", scuf.FgBlack, scuf.BgRGB(scuf.MustParseHexRGB("#B9BFCA"))).
+		NL().TAB().
+		Gradient("gradient from red to blue", scuf.RGBColor(scuf.MustParseHexRGB("#E88388")), scuf.RGBColor(scuf.MustParseHexRGB("#71BEF2"))).
 		NL().NL()
 
 	hw := "Hello, world!"
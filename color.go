@@ -0,0 +1,349 @@
+package scuf
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type colorKind uint8
+
+const (
+	colorNone colorKind = iota
+	colorDefault
+	colorANSI16
+	colorANSI256
+	colorRGB
+)
+
+// Color is terminal color: basic ANSI color, ANSI-256 color or 24-bit RGB color.
+// Zero value is no color, it produces no modifiers.
+type Color struct {
+	kind colorKind
+	// index is ANSI color index, used for ANSI colors
+	index uint8
+	// r, g, b are used for RGB colors
+	r, g, b uint8
+}
+
+var _ color.Color = Color{}
+
+// DefaultColor is terminal default color, it resets foreground, background or underline color
+var DefaultColor = Color{kind: colorDefault}
+
+// ANSIColor creates color from ANSI color index, 0-15 are basic colors, 16-255 are extended ones.
+// Returns no color if index is out of range.
+func ANSIColor(i int) Color {
+	switch {
+	case i < 0 || i >= 256:
+		return Color{}
+	case i < 16:
+		return Color{kind: colorANSI16, index: uint8(i)}
+	default:
+		return Color{kind: colorANSI256, index: uint8(i)}
+	}
+}
+
+// RGBColor creates 24-bit color, r,g,b are 0-255
+func RGBColor(r, g, b uint8) Color {
+	return Color{kind: colorRGB, r: r, g: g, b: b}
+}
+
+// parseHex parses hex color string, in form "#f0c", "#f0c8", "#ff1034" or "#ff103480".
+// Alpha is ignored since terminals don't support transparency.
+func parseHex(hex string) (Color, error) {
+	digits, ok := strings.CutPrefix(hex, "#")
+	if !ok {
+		return Color{}, fmt.Errorf("color %q: hex color must start with #", hex)
+	}
+
+	if len(digits) != 3 && len(digits) != 4 && len(digits) != 6 && len(digits) != 8 {
+		return Color{}, fmt.Errorf("color %q: hex color must have 3, 4, 6 or 8 digits, got %d", hex, len(digits))
+	}
+	// all digits are checked, including alpha ones
+	if _, err := strconv.ParseUint(digits, 16, 32); err != nil {
+		return Color{}, fmt.Errorf("color %q: invalid hex digits", hex)
+	}
+
+	if len(digits) <= 4 {
+		// each digit is repeated: #f0c -> #ff00cc
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	v, _ := strconv.ParseUint(digits[:6], 16, 32)
+	return RGBColor(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+}
+
+// ParseColor parses color from string. Supported formats are:
+//   - hex: "#f0c", "#ff1034", "#ff103480", alpha is ignored
+//   - functional notation: "rgb(255, 16, 52)", "rgb(100% 6% 20%)", "hsl(350, 100%, 53%)"
+//   - CSS/X11 color names: "red", "rebeccapurple", "Light Slate Gray"
+//   - ANSI color index: "ansi:9", "196"
+func ParseColor(s string) (Color, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return Color{}, fmt.Errorf("empty color")
+	case strings.HasPrefix(s, "#"):
+		return parseHex(s)
+	case strings.HasPrefix(lower, "ansi:"):
+		return parseANSIIndex(s, s[len("ansi:"):])
+	case strings.Trim(s, "0123456789") == "":
+		return parseANSIIndex(s, s)
+	case strings.HasPrefix(lower, "rgb(") || strings.HasPrefix(lower, "rgba("):
+		args, err := parseColorArgs(s)
+		if err != nil {
+			return Color{}, err
+		}
+
+		var rgb [3]uint8
+		for i, arg := range args {
+			v, err := parseColorNumber(arg, 255, 255)
+			if err != nil {
+				return Color{}, fmt.Errorf("color %q: component %d: %w", s, i+1, err)
+			}
+			rgb[i] = uint8(math.Round(v))
+		}
+		return RGBColor(rgb[0], rgb[1], rgb[2]), nil
+	case strings.HasPrefix(lower, "hsl(") || strings.HasPrefix(lower, "hsla("):
+		args, err := parseColorArgs(s)
+		if err != nil {
+			return Color{}, err
+		}
+
+		h, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(args[0]), "deg"), 64)
+		if err != nil || math.IsNaN(h) || math.IsInf(h, 0) {
+			return Color{}, fmt.Errorf("color %q: invalid hue %q", s, args[0])
+		}
+		// unitless saturation and lightness are percentages, as in CSS Color 4
+		sat, err := parseColorNumber(args[1], 100, 1)
+		if err != nil {
+			return Color{}, fmt.Errorf("color %q: saturation: %w", s, err)
+		}
+		l, err := parseColorNumber(args[2], 100, 1)
+		if err != nil {
+			return Color{}, fmt.Errorf("color %q: lightness: %w", s, err)
+		}
+		return hslToRGB(h, sat, l), nil
+	}
+
+	if v, ok := colorNames[strings.ReplaceAll(lower, " ", "")]; ok {
+		return RGBColor(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+	}
+	return Color{}, fmt.Errorf("unknown color %q, expected hex, rgb(), hsl(), color name or ANSI index", s)
+}
+
+// parseANSIIndex parses ANSI color index 0-255
+func parseANSIIndex(s, index string) (Color, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i > 255 {
+		return Color{}, fmt.Errorf("color %q: ANSI color index must be integer 0-255", s)
+	}
+	return ANSIColor(i), nil
+}
+
+// parseColorArgs parses 3 arguments of functional notation like "rgb(1, 2, 3)" or "rgb(1 2 3 / 50%)",
+// optional fourth alpha argument is ignored
+func parseColorArgs(s string) ([]string, error) {
+	open := strings.IndexByte(s, '(')
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("color %q: missing closing parenthesis", s)
+	}
+
+	args := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool {
+		return r == ',' || r == '/' || unicode.IsSpace(r)
+	})
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("color %q: expected 3 or 4 arguments, got %d", s, len(args))
+	}
+	return args[:3], nil
+}
+
+// parseColorNumber parses number in range [0, max] or percentage, result is scaled so that
+// max and 100% are both scale
+func parseColorNumber(s string, max, scale float64) (float64, error) {
+	if v, ok := strings.CutSuffix(s, "%"); ok {
+		s, max = v, 100
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid number %q", s)
+	case !(v >= 0 && v <= max): // also catches NaN
+		return 0, fmt.Errorf("%v is out of range [0, %v]", v, max)
+	default:
+		return v * scale / max, nil
+	}
+}
+
+// hslToRGB converts color from HSL, h is hue in degrees, s and l are in [0, 1]
+func hslToRGB(h, s, l float64) Color {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	to8 := func(v float64) uint8 {
+		return uint8(math.Round((v + m) * 255))
+	}
+	return RGBColor(to8(r), to8(g), to8(b))
+}
+
+// parseColorModifier parses color from single color modifier, e.g. FgRed or BgRGB(1, 2, 3)
+func parseColorModifier(mod Modifier) (Color, bool) {
+	params := strings.Split(string(mod), ";")
+	atoi := func(s string) int {
+		i, err := strconv.Atoi(s)
+		return ternary(err == nil && i >= 0 && i < 256, i, -1)
+	}
+
+	switch code := atoi(params[0]); {
+	case len(params) == 1 && (code >= 30 && code <= 37 || code >= 40 && code <= 47):
+		return ANSIColor(code % 10), true
+	case len(params) == 1 && (code >= 90 && code <= 97 || code >= 100 && code <= 107):
+		return ANSIColor(code%10 + 8), true
+	case code != 38 && code != 48 && code != 58 || len(params) < 3:
+		return Color{}, false
+	case params[1] == "5" && len(params) == 3 && atoi(params[2]) != -1:
+		return ANSIColor(atoi(params[2])), true
+	case params[1] == "2" && len(params) == 5 && atoi(params[2]) != -1 && atoi(params[3]) != -1 && atoi(params[4]) != -1:
+		return RGBColor(uint8(atoi(params[2])), uint8(atoi(params[3])), uint8(atoi(params[4]))), true
+	default:
+		return Color{}, false
+	}
+}
+
+// IsNone checks whether color is no color
+func (c Color) IsNone() bool {
+	return c.kind == colorNone
+}
+
+// isConcrete checks whether color has actual rgb value, i.e. it is not no color or default color
+func (c Color) isConcrete() bool {
+	return c.kind != colorNone && c.kind != colorDefault
+}
+
+// ANSI returns ANSI color index, if color is ANSI color
+func (c Color) ANSI() (int, bool) {
+	return int(c.index), c.kind == colorANSI16 || c.kind == colorANSI256
+}
+
+// Fg returns modifier setting color as foreground
+func (c Color) Fg() Modifier {
+	switch c.kind {
+	case colorANSI16, colorANSI256:
+		return FgANSI(int(c.index))
+	case colorRGB:
+		return FgRGB(c.r, c.g, c.b)
+	case colorDefault:
+		return ModFgDefault
+	default:
+		return nil
+	}
+}
+
+// Bg returns modifier setting color as background
+func (c Color) Bg() Modifier {
+	switch c.kind {
+	case colorANSI16, colorANSI256:
+		return BgANSI(int(c.index))
+	case colorRGB:
+		return BgRGB(c.r, c.g, c.b)
+	case colorDefault:
+		return ModBgDefault
+	default:
+		return nil
+	}
+}
+
+// Underline returns modifier setting color of underline
+func (c Color) Underline() Modifier {
+	switch c.kind {
+	case colorANSI16, colorANSI256:
+		return UnderlineANSI(int(c.index))
+	case colorRGB:
+		return UnderlineRGB(c.r, c.g, c.b)
+	case colorDefault:
+		return ModUnderlineColorDefault
+	default:
+		return nil
+	}
+}
+
+// RGB returns r,g,b components of color. ANSI colors are converted using xterm palette.
+// No color and default color are black.
+func (c Color) RGB() (r, g, b uint8) {
+	switch c.kind {
+	case colorANSI16, colorANSI256:
+		rgb := ansiRGB[c.index]
+		return rgb[0], rgb[1], rgb[2]
+	case colorRGB:
+		return c.r, c.g, c.b
+	default:
+		return 0, 0, 0
+	}
+}
+
+// Hex returns color in form "#ff1034"
+func (c Color) Hex() string {
+	r, g, b := c.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// RGBA implements color.Color
+func (c Color) RGBA() (r, g, b, a uint32) {
+	r8, g8, b8 := c.RGB()
+	return uint32(r8) * 0x101, uint32(g8) * 0x101, uint32(b8) * 0x101, 0xffff
+}
+
+// Luminance returns WCAG relative luminance of color, 0 is darkest black and 1 is lightest white
+func (c Color) Luminance() float64 {
+	r, g, b := c.RGB()
+	return 0.2126*srgbToLinear(r) + 0.7152*srgbToLinear(g) + 0.0722*srgbToLinear(b)
+}
+
+// Contrast returns WCAG contrast ratio between colors, from 1 for same colors to 21 for black and white.
+// WCAG requires at least 4.5 for normal text and 3 for large text.
+func Contrast(a, b Color) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// ReadableOn returns candidate having best contrast with background.
+// If no candidates given, black or white is returned.
+func ReadableOn(bg Color, candidates ...Color) Color {
+	if len(candidates) == 0 {
+		candidates = []Color{RGBColor(0, 0, 0), RGBColor(255, 255, 255)}
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if Contrast(c, bg) > Contrast(best, bg) {
+			best = c
+		}
+	}
+	return best
+}
@@ -0,0 +1,157 @@
+package scuf
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColor(t *testing.T) {
+	for name, test := range map[string]struct {
+		color             Color
+		fg, bg, underline Modifier
+		hex               string
+		rgb               [3]uint8
+	}{
+		"none":    {Color{}, nil, nil, nil, "#000000", [3]uint8{0, 0, 0}},
+		"ansi16":  {ANSIColor(9), FgHiRed, BgHiRed, Modifier("58;5;9"), "#ff0000", [3]uint8{0xff, 0, 0}},
+		"ansi256": {ANSIColor(91), FgANSI(91), BgANSI(91), Modifier("58;5;91"), "#8700af", [3]uint8{0x87, 0, 0xaf}},
+		"rgb":     {RGBColor(0xab, 0xcd, 0xef), FgRGB(0xab, 0xcd, 0xef), BgRGB(0xab, 0xcd, 0xef), Modifier("58;2;171;205;239"), "#abcdef", [3]uint8{0xab, 0xcd, 0xef}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.fg, test.color.Fg())
+			assert.Equal(t, test.bg, test.color.Bg())
+			assert.Equal(t, test.underline, test.color.Underline())
+			assert.Equal(t, test.hex, test.color.Hex())
+
+			r, g, b := test.color.RGB()
+			assert.Equal(t, test.rgb, [3]uint8{r, g, b})
+		})
+	}
+}
+
+func TestColorANSI(t *testing.T) {
+	i, ok := ANSIColor(91).ANSI()
+	assert.True(t, ok)
+	assert.Equal(t, 91, i)
+
+	_, ok = RGBColor(1, 2, 3).ANSI()
+	assert.False(t, ok)
+
+	assert.True(t, Color{}.IsNone())
+	assert.True(t, ANSIColor(-1).IsNone())
+	assert.True(t, ANSIColor(256).IsNone())
+	assert.False(t, ANSIColor(0).IsNone())
+}
+
+func TestColorImplementsColor(t *testing.T) {
+	c := color.NRGBAModel.Convert(RGBColor(1, 2, 3)).(color.NRGBA)
+	assert.Equal(t, color.NRGBA{1, 2, 3, 0xff}, c)
+}
+
+func TestMustParseHexRGB(t *testing.T) {
+	for hex, expected := range map[string][3]uint8{
+		"#ff1034": {0xff, 0x10, 0x34},
+		"#f0c":    {0xff, 0x00, 0xcc},
+		"#FFF":    {0xff, 0xff, 0xff},
+		"ff1034":  {0, 0, 0},
+		"#ff103":  {0, 0, 0},
+		"#gg1034": {0, 0, 0},
+	} {
+		t.Run(hex, func(t *testing.T) {
+			r, g, b := MustParseHexRGB(hex)
+			assert.Equal(t, expected, [3]uint8{r, g, b})
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for s, expected := range map[string]Color{
+		"#f0c":                     RGBColor(0xff, 0x00, 0xcc),
+		"#ff1034":                  RGBColor(0xff, 0x10, 0x34),
+		"#FF1034":                  RGBColor(0xff, 0x10, 0x34),
+		"#ff103480":                RGBColor(0xff, 0x10, 0x34),
+		"  #abc  ":                 RGBColor(0xaa, 0xbb, 0xcc),
+		"rgb(255, 16, 52)":         RGBColor(0xff, 0x10, 0x34),
+		"rgb(255 16 52)":           RGBColor(0xff, 0x10, 0x34),
+		"RGBA(255, 16, 52, 0.5)":   RGBColor(0xff, 0x10, 0x34),
+		"rgb(100% 0% 50% / 50%)":   RGBColor(0xff, 0x00, 0x80),
+		"hsl(0, 100%, 50%)":        RGBColor(0xff, 0x00, 0x00),
+		"hsl(120deg 100% 25%)":     RGBColor(0x00, 0x80, 0x00),
+		"hsl(-120, 100%, 50%)":     RGBColor(0x00, 0x00, 0xff),
+		"hsla(270, 50%, 40%, 0.1)": RGBColor(0x66, 0x33, 0x99),
+		"hsl(0, 100, 50)":          RGBColor(0xff, 0x00, 0x00),
+		"hsl(0 1 0.5)":             RGBColor(0x01, 0x01, 0x01),
+		"red":                      RGBColor(0xff, 0x00, 0x00),
+		"RebeccaPurple":            RGBColor(0x66, 0x33, 0x99),
+		"light slate gray":         RGBColor(0x77, 0x88, 0x99),
+		"ansi:9":                   ANSIColor(9),
+		"ANSI:196":                 ANSIColor(196),
+		"0":                        ANSIColor(0),
+		"255":                      ANSIColor(255),
+	} {
+		t.Run(s, func(t *testing.T) {
+			c, err := ParseColor(s)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, c)
+		})
+	}
+}
+
+func TestParseColorErrors(t *testing.T) {
+	for s, expected := range map[string]string{
+		"":                  `empty color`,
+		"#ff103":            `color "#ff103": hex color must have 3, 4, 6 or 8 digits, got 5`,
+		"#gg1034":           `color "#gg1034": invalid hex digits`,
+		"#f0cz":             `color "#f0cz": invalid hex digits`,
+		"#ff1034zz":         `color "#ff1034zz": invalid hex digits`,
+		"256":               `color "256": ANSI color index must be integer 0-255`,
+		"ansi:-1":           `color "ansi:-1": ANSI color index must be integer 0-255`,
+		"rgb(1, 2, 3":       `color "rgb(1, 2, 3": missing closing parenthesis`,
+		"rgb(1, 2)":         `color "rgb(1, 2)": expected 3 or 4 arguments, got 2`,
+		"rgb(1, 2, 256)":    `color "rgb(1, 2, 256)": component 3: 256 is out of range [0, 255]`,
+		"rgb(1, x, 3)":      `color "rgb(1, x, 3)": component 2: invalid number "x"`,
+		"rgb(NaN, 1, 3)":    `color "rgb(NaN, 1, 3)": component 1: NaN is out of range [0, 255]`,
+		"hsl(x, 100%, 50%)": `color "hsl(x, 100%, 50%)": invalid hue "x"`,
+		"hsl(0, 101%, 50%)": `color "hsl(0, 101%, 50%)": saturation: 101 is out of range [0, 100]`,
+		"hsl(0, 100%, -1%)": `color "hsl(0, 100%, -1%)": lightness: -1 is out of range [0, 100]`,
+		"redd":              `unknown color "redd", expected hex, rgb(), hsl(), color name or ANSI index`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			_, err := ParseColor(s)
+			assert.EqualError(t, err, expected)
+		})
+	}
+}
+
+func TestContrast(t *testing.T) {
+	black, white := RGBColor(0, 0, 0), RGBColor(255, 255, 255)
+	assert.InDelta(t, 21, Contrast(black, white), 1e-9)
+	assert.InDelta(t, 21, Contrast(white, black), 1e-9)
+	assert.InDelta(t, 1, Contrast(ANSIColor(9), RGBColor(255, 0, 0)), 1e-9)
+	assert.InDelta(t, 4.0, Contrast(RGBColor(255, 0, 0), white), 0.01)
+	assert.InDelta(t, 8.59, Contrast(RGBColor(0, 0, 255), white), 0.01)
+}
+
+func TestReadableOn(t *testing.T) {
+	black, white := ANSIColor(0), ANSIColor(15)
+	for name, test := range map[string]struct {
+		bg         Color
+		candidates []Color
+		expected   Color
+	}{
+		"dark bg":              {ANSIColor(4), []Color{black, white}, white},
+		"light bg":             {ANSIColor(11), []Color{black, white}, black},
+		"gray ramp dark":       {ANSIColor(240), []Color{black, white}, white},
+		"gray ramp light":      {ANSIColor(250), []Color{black, white}, black},
+		"default candidates":   {RGBColor(MustParseHexRGB("#ffd700")), nil, RGBColor(0, 0, 0)},
+		"default candidates 2": {RGBColor(MustParseHexRGB("#191970")), nil, RGBColor(255, 255, 255)},
+		"single candidate":     {white, []Color{white}, white},
+		"more candidates":      {RGBColor(MustParseHexRGB("#222222")), []Color{ANSIColor(4), ANSIColor(11), ANSIColor(8)}, ANSIColor(11)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ReadableOn(test.bg, test.candidates...))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package scuf
+
+import "strings"
+
+// escapeLen returns length of escape sequence at start of s, s must start with ESC. Recognized
+// sequences are CSI, string sequences (OSC, DCS, APC, PM, SOS), SS2/SS3 and two byte sequences.
+// String sequences are aborted by newline, so that stray sequence doesn't swallow following lines.
+// If sequence is not terminated, whole s is sequence and complete is false.
+func escapeLen(s string) (n int, complete bool) {
+	if len(s) < 2 {
+		return len(s), false
+	}
+
+	switch s[1] {
+	case '[': // CSI: parameter and intermediate bytes, then final byte
+		for i := 2; i < len(s); i++ {
+			switch c := s[i]; {
+			case c >= 0x40 && c <= 0x7E:
+				return i + 1, true
+			case c < 0x20 || c > 0x7E:
+				// invalid byte aborts sequence
+				return i, true
+			}
+		}
+		return len(s), false
+	case ']', 'P', '_', '^', 'X': // OSC, DCS, APC, PM, SOS: string terminated by ST, OSC also by BEL
+		for i := 2; i < len(s); i++ {
+			switch {
+			case s[i] == '\a' && s[1] == ']':
+				return i + 1, true
+			case s[i] == '\n':
+				return i, true
+			case s[i] != _esc:
+			case i+1 == len(s):
+				return len(s), false
+			case s[i+1] == '\\':
+				return i + 2, true
+			default:
+				// other escape sequence aborts string
+				return i, true
+			}
+		}
+		return len(s), false
+	case 'N', 'O': // SS2, SS3: single shift of next character
+		if len(s) < 3 {
+			return len(s), false
+		}
+		return 3, true
+	default: // intermediate bytes, then final byte, e.g. ESC ( B
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c >= 0x30 && c <= 0x7E:
+				return i + 1, true
+			case c < 0x20 || c > 0x7E:
+				return i, true
+			}
+		}
+		return len(s), false
+	}
+}
+
+// escapeState is style and hyperlink set by escape sequences
+type escapeState struct {
+	pen pen
+	// link is OSC 8 sequence of open hyperlink, empty if none
+	link string
+}
+
+// apply updates state by escape sequence
+func (st *escapeState) apply(seq string) {
+	switch {
+	case strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m"):
+		st.pen.apply(Modifier(seq[2 : len(seq)-1]))
+	case strings.HasPrefix(seq, "\x1b]8;"):
+		params := strings.TrimSuffix(strings.TrimSuffix(seq, "\a"), "\x1b\\")
+		// params are "8;options;uri", empty uri ends hyperlink
+		st.link = ternary(strings.Count(params, ";") < 2 || strings.HasSuffix(params, ";"), "", seq)
+	}
+}
+
+// scan updates state by all escape sequences in s
+func (st *escapeState) scan(s string) {
+	for i := strings.IndexByte(s, _esc); i != -1; i = strings.IndexByte(s, _esc) {
+		n, _ := escapeLen(s[i:])
+		st.apply(s[i : i+n])
+		s = s[i+n:]
+	}
+}
+
+// open returns escape sequences setting state from default one
+func (st escapeState) open() string {
+	res := st.link
+	if st.pen != (pen{}) {
+		res += string(_csi) + strings.Join(st.pen.codes(), ";") + "m"
+	}
+	return res
+}
+
+// close returns escape sequences bringing state to default one
+func (st escapeState) close() string {
+	res := ""
+	if st.pen != (pen{}) {
+		res += string(_sgrReset)
+	}
+	if st.link != "" {
+		res += "\x1b]8;;\x1b\\"
+	}
+	return res
+}
@@ -0,0 +1,24 @@
+package scuf
+
+import "fmt"
+
+// StyledValue is value formatted with modifiers, created by Styled
+type StyledValue struct {
+	value any
+	mods  []Modifier
+}
+
+var _ fmt.Formatter = StyledValue{}
+
+// Styled wraps value so that it is formatted by fmt with given modifiers. Width, precision
+// and flags apply to visible text, escape codes are added around padded result, e.g.
+//
+//	fmt.Printf("%-10v|", scuf.Styled("error", scuf.FgRed))
+func Styled(value any, mods ...Modifier) StyledValue {
+	return StyledValue{value: value, mods: mods}
+}
+
+// Format implements fmt.Formatter
+func (v StyledValue) Format(f fmt.State, verb rune) {
+	New(f).String(fmt.Sprintf(fmt.FormatString(f, verb), v.value), v.mods...)
+}
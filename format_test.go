@@ -0,0 +1,37 @@
+package scuf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStyledValue(t *testing.T) {
+	for name, test := range map[string]struct {
+		format   string
+		value    any
+		expected string
+	}{
+		"plain":      {"%v", "abc", "\x1b[31mabc\x1b[0m"},
+		"left pad":   {"%-6v|", "abc", "\x1b[31mabc   \x1b[0m|"},
+		"right pad":  {"%6s|", "abc", "\x1b[31m   abc\x1b[0m|"},
+		"precision":  {"%.2s", "abc", "\x1b[31mab\x1b[0m"},
+		"float":      {"%8.3f", 3.14159, "\x1b[31m   3.142\x1b[0m"},
+		"zero pad":   {"%05d", 42, "\x1b[31m00042\x1b[0m"},
+		"plus flag":  {"%+d", 42, "\x1b[31m+42\x1b[0m"},
+		"sharp flag": {"%#x", 255, "\x1b[31m0xff\x1b[0m"},
+		"quoted":     {"%q", "a", "\x1b[31m\"a\"\x1b[0m"},
+		"struct":     {"%+v", struct{ A int }{1}, "\x1b[31m{A:1}\x1b[0m"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, fmt.Sprintf(test.format, Styled(test.value, FgRed)))
+		})
+	}
+}
+
+func TestStyledValueNoModifiers(t *testing.T) {
+	assert.Equal(t, "ab  |", fmt.Sprintf("%-4v|", Styled("ab")))
+	assert.Equal(t, "\x1b[31m    x\x1b[0m|", fmt.Sprintf("%*v|", 5, Styled("x", FgRed)))
+	assert.Equal(t, "\x1b[1;31mab\x1b[0m", fmt.Sprint(Styled("ab", ModBold, FgRed)))
+}
@@ -0,0 +1,121 @@
+package scuf
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// isExtend checks whether rune extends previous grapheme cluster:
+// combining marks, zero width joiner, variation selectors, emoji modifiers and tags
+func isExtend(r rune) bool {
+	switch {
+	case r == 0x200D, // zero width joiner
+		r >= 0xFE00 && r <= 0xFE0F,   // variation selectors
+		r >= 0x1F3FB && r <= 0x1F3FF, // emoji skin tone modifiers
+		r >= 0xE0020 && r <= 0xE007F, // tags
+		r >= 0xE0100 && r <= 0xE01EF: // variation selectors supplement
+		return true
+	default:
+		return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+	}
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isPictographic approximates Extended_Pictographic property
+func isPictographic(r rune) bool {
+	return r == 0x00A9 || r == 0x00AE ||
+		r >= 0x2190 && r <= 0x21FF ||
+		r >= 0x2300 && r <= 0x23FF ||
+		r >= 0x2600 && r <= 0x27BF ||
+		r >= 0x2B00 && r <= 0x2BFF ||
+		r >= 0x1F000 && r <= 0x1FAFF
+}
+
+// hangul syllable types
+const (
+	hangulNone = iota
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+func hangulType(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return hangulL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return hangulV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return hangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		return ternary((r-0xAC00)%28 == 0, hangulLV, hangulLVT)
+	default:
+		return hangulNone
+	}
+}
+
+// hangulJoins checks whether hangul jamo or syllables of given types form single syllable
+func hangulJoins(prev, next int) bool {
+	switch prev {
+	case hangulL:
+		return next == hangulL || next == hangulV || next == hangulLV || next == hangulLVT
+	case hangulV, hangulLV:
+		return next == hangulV || next == hangulT
+	case hangulT, hangulLVT:
+		return next == hangulT
+	default:
+		return false
+	}
+}
+
+// graphemeLen returns length in bytes of first grapheme cluster in s.
+// It is approximation of UAX #29 extended grapheme clusters.
+func graphemeLen(s string) int {
+	if s == "" {
+		return 0
+	}
+
+	prev, n := utf8.DecodeRuneInString(s)
+	if prev == '\r' && len(s) > 1 && s[1] == '\n' {
+		return 2
+	}
+	if prev < 0x20 || prev == 0x7F {
+		return n
+	}
+
+	regionalIndicators := ternary(isRegionalIndicator(prev), 1, 0)
+	pictographic := isPictographic(prev)
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case isExtend(r):
+		case prev == 0x200D && pictographic && isPictographic(r):
+		case isRegionalIndicator(r) && regionalIndicators%2 == 1:
+			regionalIndicators++
+		case hangulJoins(hangulType(prev), hangulType(r)):
+		default:
+			return n
+		}
+
+		prev = r
+		pictographic = pictographic || isPictographic(r)
+		n += size
+	}
+	return n
+}
+
+// graphemes splits string into grapheme clusters
+func graphemes(s string) []string {
+	res := []string{}
+	for s != "" {
+		n := graphemeLen(s)
+		res = append(res, s[:n])
+		s = s[n:]
+	}
+	return res
+}
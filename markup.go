@@ -0,0 +1,292 @@
+package scuf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarkupError is error in markup, Pos is byte offset in markup string where it occurred
+type MarkupError struct {
+	Pos int
+	Msg string
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("parse markup: position %d: %s", e.Pos, e.Msg)
+}
+
+// markupAttrs are attribute names usable in markup tags
+var markupAttrs = map[string]Attr{
+	"bold":             AttrBold,
+	"b":                AttrBold,
+	"faint":            AttrFaint,
+	"dim":              AttrFaint,
+	"italic":           AttrItalic,
+	"i":                AttrItalic,
+	"underline":        AttrUnderline,
+	"u":                AttrUnderline,
+	"double-underline": AttrUnderlineDouble,
+	"curly-underline":  AttrUnderlineCurly,
+	"dotted-underline": AttrUnderlineDotted,
+	"dashed-underline": AttrUnderlineDashed,
+	"blink":            AttrBlink,
+	"reverse":          AttrReverse,
+	"conceal":          AttrConceal,
+	"crossout":         AttrCrossout,
+	"strike":           AttrCrossout,
+	"s":                AttrCrossout,
+	"overline":         AttrOverline,
+	"framed":           AttrFramed,
+	"encircled":        AttrEncircled,
+	"superscript":      AttrSuperscript,
+	"subscript":        AttrSubscript,
+}
+
+// markupColors are names of basic ANSI colors, bright ones are prefixed with "bright-"
+var markupColors = [...]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// markupNode is text or tag with children nodes
+type markupNode struct {
+	// text is text of text node
+	text string
+	// tag is tag contents, empty for text node
+	tag      string
+	pos      int
+	style    Style
+	link     string
+	children []markupNode
+}
+
+// Markup is parsed inline style markup, see ParseMarkup
+type Markup struct {
+	nodes []markupNode
+}
+
+// ParseMarkup parses inline style markup, e.g.
+//
+//	[bold red]Error:[/] file [u link=file:///x.go]x.go[/] not found
+//
+// Tag contains space separated words:
+//   - attributes: bold, faint, italic, underline, blink, reverse, strike, etc., "not" before attribute turns it off
+//   - foreground color: basic ANSI color name like "red" or "bright-red", or any color accepted by ParseColor
+//     without spaces, e.g. "#ff1034", "rebeccapurple", "ansi:196"
+//   - background color: "on" followed by color
+//   - fg=color, bg=color, ul=color to set foreground, background or underline color
+//   - link=url to make hyperlink
+//
+// Tags are closed by [/] or by [/tag], where tag must be same as in opening tag.
+// Literal brackets are escaped with backslash: \[, literal backslash is \\.
+// Errors are of type *MarkupError.
+func ParseMarkup(s string) (Markup, error) {
+	// stack of open tags, root is bottom
+	stack := []markupNode{{}}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			top := &stack[len(stack)-1]
+			top.children = append(top.children, markupNode{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '[' || s[i+1] == '\\'):
+			text.WriteByte(s[i+1])
+			i++
+		case c == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return Markup{}, &MarkupError{i, "tag is not terminated by ]"}
+			}
+
+			flush()
+			tag := s[i+1 : i+end]
+			if name, ok := strings.CutPrefix(tag, "/"); ok {
+				if len(stack) == 1 {
+					return Markup{}, &MarkupError{i, fmt.Sprintf("closing tag [%s] without opening one", tag)}
+				}
+
+				node := stack[len(stack)-1]
+				if name != "" && name != node.tag {
+					return Markup{}, &MarkupError{i, fmt.Sprintf("closing tag [%s] doesn't match [%s] opened at position %d", tag, node.tag, node.pos)}
+				}
+
+				stack = stack[:len(stack)-1]
+				top := &stack[len(stack)-1]
+				top.children = append(top.children, node)
+			} else {
+				node, err := parseMarkupTag(tag, i+1)
+				if err != nil {
+					return Markup{}, err
+				}
+				node.pos = i
+				stack = append(stack, node)
+			}
+			i += end
+		default:
+			text.WriteByte(c)
+		}
+	}
+
+	flush()
+	if len(stack) > 1 {
+		node := stack[len(stack)-1]
+		return Markup{}, &MarkupError{node.pos, fmt.Sprintf("tag [%s] is not closed", node.tag)}
+	}
+	return Markup{nodes: stack[0].children}, nil
+}
+
+// parseMarkupTag parses tag contents, pos is position of contents in markup string
+func parseMarkupTag(tag string, pos int) (markupNode, error) {
+	node := markupNode{tag: tag}
+	words := strings.Split(tag, " ")
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		wordPos := pos + len(strings.Join(words[:i], " ")) + ternary(i > 0, 1, 0)
+		errorf := func(format string, args ...any) error {
+			return &MarkupError{wordPos, fmt.Sprintf(format, args...)}
+		}
+
+		if key, value, ok := strings.Cut(word, "="); ok {
+			var set func(Style, Color) Style
+			switch key {
+			case "link":
+				node.link = value
+				continue
+			case "fg":
+				set = Style.Fg
+			case "bg":
+				set = Style.Bg
+			case "ul":
+				set = Style.Ul
+			default:
+				return markupNode{}, errorf("unknown key %q", key)
+			}
+
+			c, err := parseMarkupColor(value)
+			if err != nil {
+				return markupNode{}, errorf("%s: %s", key, err.Error())
+			}
+			node.style = set(node.style, c)
+			continue
+		}
+
+		switch word {
+		case "":
+			if tag == "" {
+				return markupNode{}, errorf("empty tag")
+			}
+			continue
+		case "not", "on":
+			if i+1 == len(words) {
+				return markupNode{}, errorf("%q must be followed by %s", word, ternary(word == "on", "color", "attribute"))
+			}
+		}
+
+		switch {
+		case word == "not":
+			attr, ok := markupAttrs[words[i+1]]
+			if !ok {
+				return markupNode{}, errorf("unknown attribute %q", words[i+1])
+			}
+			node.style = node.style.Without(attr)
+			i++
+		case word == "on":
+			c, err := parseMarkupColor(words[i+1])
+			if err != nil {
+				return markupNode{}, errorf("%s", err.Error())
+			}
+			node.style = node.style.Bg(c)
+			i++
+		default:
+			if attr, ok := markupAttrs[word]; ok {
+				node.style = node.style.With(attr)
+				continue
+			}
+
+			c, err := parseMarkupColor(word)
+			if err != nil {
+				return markupNode{}, errorf("unknown style %q", word)
+			}
+			node.style = node.style.Fg(c)
+		}
+	}
+	return node, nil
+}
+
+// parseMarkupColor parses color from basic ANSI color name, "default" or ParseColor format
+func parseMarkupColor(s string) (Color, error) {
+	if s == "default" {
+		return DefaultColor, nil
+	}
+
+	name, bright := strings.CutPrefix(s, "bright-")
+	for i, c := range markupColors {
+		if name == c {
+			return ANSIColor(i + ternary(bright, 8, 0)), nil
+		}
+	}
+	return ParseColor(s)
+}
+
+// MustParseMarkup is like ParseMarkup, but panics on error
+func MustParseMarkup(s string) Markup {
+	m, err := ParseMarkup(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Plain returns markup text without styles
+func (m Markup) Plain() string {
+	var sb strings.Builder
+	var walk func([]markupNode)
+	walk = func(nodes []markupNode) {
+		for _, node := range nodes {
+			sb.WriteString(node.text)
+			walk(node.children)
+		}
+	}
+	walk(m.nodes)
+	return sb.String()
+}
+
+// StripMarkup removes markup tags from string, leaving only text
+func StripMarkup(s string) (string, error) {
+	m, err := ParseMarkup(s)
+	if err != nil {
+		return "", err
+	}
+	return m.Plain(), nil
+}
+
+// Markup writes markup to buffer
+func (b Buffer) Markup(m Markup) Buffer {
+	b.markup(m.nodes, "")
+	return b
+}
+
+// markup writes markup nodes, link is hyperlink of enclosing tags
+func (b Buffer) markup(nodes []markupNode, link string) {
+	for _, node := range nodes {
+		if node.tag == "" {
+			b.String(node.text)
+			continue
+		}
+
+		b.Styled(func(b Buffer) {
+			if node.link == "" {
+				b.markup(node.children, link)
+				return
+			}
+
+			b.setLink(node.link)
+			b.markup(node.children, node.link)
+			// restore enclosing hyperlink
+			b.setLink(link)
+		}, node.style.Modifier())
+	}
+}
@@ -0,0 +1,100 @@
+package scuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkup(t *testing.T) {
+	for name, test := range map[string]struct {
+		markup   string
+		expected string
+		plain    string
+	}{
+		"plain text": {
+			"hello",
+			"hello",
+			"hello",
+		},
+		"example": {
+			"[bold red]Error:[/] file [u link=file:///x]x.go[/] not found",
+			"\x1b[1;31mError:\x1b[0m file \x1b[4m\x1b]8;;file:///x\x1b\\x.go\x1b]8;;\x1b\\\x1b[0m not found",
+			"Error: file x.go not found",
+		},
+		"nested": {
+			"[bold]a [italic]b[/italic] c[/bold]",
+			"\x1b[1ma \x1b[3mb\x1b[0;1m c\x1b[0m",
+			"a b c",
+		},
+		"colors": {
+			"[bright-green on blue]a[/][fg=#ff0000 bg=ansi:200 ul=red curly-underline]b[/]",
+			"\x1b[92;44ma\x1b[0m\x1b[4:3;38;2;255;0;0;48;5;200;58;5;1mb\x1b[0m",
+			"ab",
+		},
+		"css color": {
+			"[rebeccapurple]a[/]",
+			"\x1b[38;2;102;51;153ma\x1b[0m",
+			"a",
+		},
+		"not": {
+			"[bold]a[not bold italic]b[/]c[/]",
+			"\x1b[1ma\x1b[22;3mb\x1b[0;1mc\x1b[0m",
+			"abc",
+		},
+		"escaped brackets": {
+			`\[not a tag] \\ \n ]`,
+			`[not a tag] \ \n ]`,
+			`[not a tag] \ \n ]`,
+		},
+		"nested links": {
+			"[link=a]x[link=b]y[/]z[/]",
+			"\x1b]8;;a\x1b\\x\x1b]8;;b\x1b\\y\x1b]8;;a\x1b\\z\x1b]8;;\x1b\\",
+			"xyz",
+		},
+		"empty tag contents": {
+			"[bold][/]",
+			"\x1b[1m\x1b[0m",
+			"",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := ParseMarkup(test.markup)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, NewString(func(b Buffer) { b.Markup(m) }))
+			assert.Equal(t, test.plain, m.Plain())
+
+			plain, err := StripMarkup(test.markup)
+			assert.NoError(t, err)
+			assert.Equal(t, test.plain, plain)
+		})
+	}
+}
+
+func TestMarkupErrors(t *testing.T) {
+	for markup, expected := range map[string]string{
+		"a [bold":           `parse markup: position 2: tag is not terminated by ]`,
+		"a []":              `parse markup: position 3: empty tag`,
+		"a [/]":             `parse markup: position 2: closing tag [/] without opening one`,
+		"[bold]a[/italic]":  `parse markup: position 7: closing tag [/italic] doesn't match [bold] opened at position 0`,
+		"x [bold red]a":     `parse markup: position 2: tag [bold red] is not closed`,
+		"[bold blod]a[/]":   `parse markup: position 6: unknown style "blod"`,
+		"[not blod]a[/]":    `parse markup: position 1: unknown attribute "blod"`,
+		"[bold on]a[/]":     `parse markup: position 6: "on" must be followed by color`,
+		"[bold fg=#ff]a[/]": `parse markup: position 6: fg: color "#ff": hex color must have 3, 4, 6 or 8 digits, got 2`,
+		"[size=10]a[/]":     `parse markup: position 1: unknown key "size"`,
+	} {
+		t.Run(markup, func(t *testing.T) {
+			_, err := ParseMarkup(markup)
+			assert.EqualError(t, err, expected)
+
+			var markupErr *MarkupError
+			assert.ErrorAs(t, err, &markupErr)
+		})
+	}
+}
+
+func TestMustParseMarkup(t *testing.T) {
+	assert.Panics(t, func() { MustParseMarkup("[") })
+	assert.Equal(t, "a", MustParseMarkup("[bold]a[/]").Plain())
+}
@@ -0,0 +1,205 @@
+package scuf
+
+import "math"
+
+// oklab is color in OKLab perceptual color space, see https://bottosson.github.io/posts/oklab
+type oklab struct {
+	l, a, b float64
+}
+
+// achromaticChroma is chroma below which color is considered gray
+const achromaticChroma = 0.02
+
+func srgbToLinear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) uint8 {
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+func rgbToOKLab(r8, g8, b8 uint8) oklab {
+	r, g, b := srgbToLinear(r8), srgbToLinear(g8), srgbToLinear(b8)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return oklab{
+		l: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// linear converts to linear sRGB, components are out of [0, 1] if color is out of gamut
+func (c oklab) linear() [3]float64 {
+	l := c.l + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.l - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.l - 0.0894841775*c.a - 1.2914855480*c.b
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return [3]float64{
+		+4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	}
+}
+
+func (c oklab) rgb() (r, g, b uint8) {
+	v := c.linear()
+	return linearToSRGB(v[0]), linearToSRGB(v[1]), linearToSRGB(v[2])
+}
+
+func (c oklab) chroma() float64 {
+	return math.Hypot(c.a, c.b)
+}
+
+// dist is perceptual distance between colors
+func (c oklab) dist(o oklab) float64 {
+	dl, da, db := c.l-o.l, c.a-o.a, c.b-o.b
+	return dl*dl + da*da + db*db
+}
+
+// oklch is color in cylindrical form of OKLab: lightness, chroma and hue in degrees
+type oklch struct {
+	l, c, h float64
+}
+
+func (c oklab) lch() oklch {
+	h := math.Atan2(c.b, c.a) * 180 / math.Pi
+	return oklch{c.l, c.chroma(), math.Mod(h+360, 360)}
+}
+
+func (c oklch) lab() oklab {
+	h := c.h * math.Pi / 180
+	return oklab{c.l, c.c * math.Cos(h), c.c * math.Sin(h)}
+}
+
+// inGamut checks whether color can be represented in sRGB
+func (c oklab) inGamut() bool {
+	const eps = 1e-4
+	for _, v := range c.linear() {
+		if v < -eps || v > 1+eps {
+			return false
+		}
+	}
+	return true
+}
+
+// color converts to sRGB color, reducing chroma if color is out of sRGB gamut
+func (c oklch) color() Color {
+	switch {
+	case c.l <= 0:
+		return RGBColor(0, 0, 0)
+	case c.l >= 1:
+		return RGBColor(255, 255, 255)
+	}
+
+	c.c = math.Max(0, c.c)
+	if !c.lab().inGamut() {
+		lo, hi := 0.0, c.c
+		for i := 0; i < 20; i++ {
+			c.c = (lo + hi) / 2
+			if c.lab().inGamut() {
+				lo = c.c
+			} else {
+				hi = c.c
+			}
+		}
+		c.c = lo
+	}
+	return RGBColor(c.lab().rgb())
+}
+
+func (c Color) lab() oklab {
+	return rgbToOKLab(c.RGB())
+}
+
+// modify color in OKLCH space, result is RGB color. No color and default color are not changed.
+func (c Color) modify(f func(*oklch)) Color {
+	if !c.isConcrete() {
+		return c
+	}
+
+	lch := c.lab().lch()
+	f(&lch)
+	return lch.color()
+}
+
+// Lighten increases perceptual lightness by amount, e.g. 0.1 makes color 10% lighter
+func (c Color) Lighten(amount float64) Color {
+	return c.modify(func(lch *oklch) {
+		lch.l += amount
+	})
+}
+
+// Darken decreases perceptual lightness by amount, e.g. 0.1 makes color 10% darker
+func (c Color) Darken(amount float64) Color {
+	return c.Lighten(-amount)
+}
+
+// Saturate increases chroma by given fraction, e.g. 0.5 makes color 1.5 times more colorful
+func (c Color) Saturate(amount float64) Color {
+	return c.modify(func(lch *oklch) {
+		lch.c *= 1 + amount
+	})
+}
+
+// Desaturate decreases chroma by given fraction, e.g. 1 makes color gray
+func (c Color) Desaturate(amount float64) Color {
+	return c.Saturate(-amount)
+}
+
+// Rotate rotates hue by given degrees
+func (c Color) Rotate(degrees float64) Color {
+	return c.modify(func(lch *oklch) {
+		lch.h += degrees
+	})
+}
+
+// Complement returns color with opposite hue
+func (c Color) Complement() Color {
+	return c.Rotate(180)
+}
+
+// Mix interpolates between colors in OKLab space, t=0 gives a, t=1 gives b.
+// If one of colors is no color or default color, other one is returned.
+func Mix(a, b Color, t float64) Color {
+	switch {
+	case !a.isConcrete():
+		return b
+	case !b.isConcrete():
+		return a
+	}
+
+	la, lb := a.lab(), b.lab()
+	return RGBColor(oklab{
+		l: la.l + (lb.l-la.l)*t,
+		a: la.a + (lb.a-la.a)*t,
+		b: la.b + (lb.b-la.b)*t,
+	}.rgb())
+}
+
+// gradientAt returns color at position t in [0, 1] of gradient with given color stops
+func gradientAt(stops []Color, t float64) Color {
+	switch len(stops) {
+	case 0:
+		return Color{}
+	case 1:
+		return stops[0]
+	}
+
+	pos := t * float64(len(stops)-1)
+	i := min(int(pos), len(stops)-2)
+	return Mix(stops[i], stops[i+1], pos-float64(i))
+}
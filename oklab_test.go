@@ -0,0 +1,47 @@
+package scuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOKLabRoundTrip(t *testing.T) {
+	for _, hex := range ansiHex {
+		c, _ := parseHex(hex)
+		assert.Equal(t, c, RGBColor(c.lab().rgb()), hex)
+		assert.Equal(t, c, c.lab().lch().color(), hex)
+	}
+}
+
+func TestColorManipulation(t *testing.T) {
+	brand := RGBColor(MustParseHexRGB("#3366cc"))
+	for name, test := range map[string]struct {
+		color    Color
+		expected string
+	}{
+		"lighten":          {brand.Lighten(0.1), "#5085ee"},
+		"darken":           {brand.Darken(0.1), "#1747ab"},
+		"lighten to white": {brand.Lighten(1), "#ffffff"},
+		"darken to black":  {brand.Darken(1), "#000000"},
+		"saturate":         {brand.Saturate(0.2), "#2461df"},
+		"desaturate":       {brand.Desaturate(1), "#6c6c6c"},
+		"rotate":           {brand.Rotate(120), "#ba363d"},
+		"complement":       {brand.Complement(), "#8c6500"},
+		"mix start":        {Mix(brand, RGBColor(255, 255, 255), 0), "#3366cc"},
+		"mix end":          {Mix(brand, RGBColor(255, 255, 255), 1), "#ffffff"},
+		"mix middle":       {Mix(RGBColor(0, 0, 0), RGBColor(255, 255, 255), 0.5), "#636363"},
+		"mix ansi":         {Mix(ANSIColor(9), ANSIColor(12), 0.5), "#8c53a2"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.color.Hex())
+		})
+	}
+}
+
+func TestColorManipulationNone(t *testing.T) {
+	assert.True(t, Color{}.Lighten(0.5).IsNone())
+	assert.True(t, Color{}.Complement().IsNone())
+	assert.Equal(t, ANSIColor(1), Mix(Color{}, ANSIColor(1), 0.5))
+	assert.Equal(t, ANSIColor(1), Mix(ANSIColor(1), Color{}, 0.5))
+}
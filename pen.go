@@ -0,0 +1,197 @@
+package scuf
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// pen is state of SGR attributes of terminal
+type pen struct {
+	attrs uint64
+	// fg, bg, ul are foreground, background and underline color parameters, empty for default color
+	fg, bg, ul string
+	// other is unknown parameters, which can be turned off only by reset
+	other string
+}
+
+// apply SGR modifier to pen
+func (p *pen) apply(mod Modifier) {
+	params := strings.Split(string(mod), ";")
+	for i := 0; i < len(params); i++ {
+		code := params[i]
+		switch code {
+		case "", "0":
+			*p = pen{}
+			continue
+		case "39":
+			p.fg = ""
+			continue
+		case "49":
+			p.bg = ""
+			continue
+		case "59":
+			p.ul = ""
+			continue
+		case "4:0":
+			code = "24"
+		case "4:1":
+			code = "4"
+		case "38", "48", "58":
+			n := colorParams(params, i)
+			code = strings.Join(params[i:i+n+1], ";")
+			i += n
+		}
+
+		switch {
+		case isColor(code, "3", "9", "38"):
+			p.fg = code
+		case isColor(code, "4", "10", "48"):
+			p.bg = code
+		case strings.HasPrefix(code, "58;"):
+			p.ul = code
+		default:
+			known := false
+			for j, attr := range attrCodes {
+				switch {
+				case code == attr.on:
+					if attr.exclusive {
+						p.off(attr.off)
+					}
+					p.attrs |= 1 << j
+					known = true
+				case code == attr.off:
+					p.attrs &^= 1 << j
+					known = true
+				}
+			}
+
+			if !known {
+				p.other = ternary(p.other == "", code, p.other+";"+code)
+			}
+		}
+	}
+}
+
+// off turns off all attributes having given off code
+func (p *pen) off(code string) {
+	for i, attr := range attrCodes {
+		if attr.off == code {
+			p.attrs &^= 1 << i
+		}
+	}
+}
+
+// has checks whether any attribute having given off code is on
+func (p pen) has(off string) bool {
+	for i, attr := range attrCodes {
+		if attr.off == off && p.attrs&(1<<i) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isColor checks whether code sets color, i.e. it is one of normal0-normal7, bright0-bright7 or extended color
+func isColor(code, normal, bright, extended string) bool {
+	switch last := code[len(code)-1]; {
+	case strings.HasPrefix(code, extended+";"):
+		return true
+	case len(code) == len(normal)+1 && strings.HasPrefix(code, normal),
+		len(code) == len(bright)+1 && strings.HasPrefix(code, bright):
+		return last >= '0' && last <= '7'
+	default:
+		return false
+	}
+}
+
+// codes returns parameters to set pen from default state
+func (p pen) codes() []string {
+	res := []string{}
+	for i, attr := range attrCodes {
+		if p.attrs&(1<<i) != 0 {
+			res = append(res, attr.on)
+		}
+	}
+	if p.fg != "" {
+		res = append(res, p.fg)
+	}
+	if p.bg != "" {
+		res = append(res, p.bg)
+	}
+	if p.ul != "" {
+		res = append(res, p.ul)
+	}
+	if p.other != "" {
+		res = append(res, p.other)
+	}
+	return res
+}
+
+// diff returns shortest parameters list to transition from pen p to pen to
+func (p pen) diff(to pen) []string {
+	reset := append([]string{"0"}, to.codes()...)
+	if p.other != "" && p.other != to.other {
+		return reset
+	}
+
+	res := []string{}
+	cleared := uint64(0)
+	for i, attr := range attrCodes {
+		if p.attrs&(1<<i) == 0 || to.attrs&(1<<i) != 0 {
+			continue
+		}
+
+		// exclusive attribute is replaced by turning on other one
+		if attr.exclusive && to.has(attr.off) {
+			continue
+		}
+
+		// off code can turn off several attributes, e.g. bold and faint
+		for j, other := range attrCodes {
+			if other.off == attr.off {
+				cleared |= 1 << j
+			}
+		}
+		if !slices.Contains(res, attr.off) {
+			res = append(res, attr.off)
+		}
+	}
+	for i, attr := range attrCodes {
+		if to.attrs&(1<<i) != 0 && (p.attrs&(1<<i) == 0 || cleared&(1<<i) != 0) {
+			res = append(res, attr.on)
+		}
+	}
+	if p.fg != to.fg {
+		res = append(res, ternary(to.fg == "", "39", to.fg))
+	}
+	if p.bg != to.bg {
+		res = append(res, ternary(to.bg == "", "49", to.bg))
+	}
+	if p.ul != to.ul {
+		res = append(res, ternary(to.ul == "", "59", to.ul))
+	}
+	if p.other != to.other {
+		res = append(res, to.other)
+	}
+
+	if len(strings.Join(reset, ";")) <= len(strings.Join(res, ";")) {
+		return reset
+	}
+	return res
+}
+
+// sync brings terminal to style of buffer. Outside of optimizing mode style could be
+// changed only by enclosing buffer written inside Styled callback.
+func (b Buffer) sync() {
+	if b.pen == nil {
+		b.w.enter(b.frame)
+		return
+	}
+	if *b.pen == b.want {
+		return
+	}
+
+	b.w.Write(bytes.Join([][]byte{_csi, []byte(strings.Join(b.pen.diff(b.want), ";")), {'m'}}, nil)) //nolint:errcheck // error is remembered by output
+	*b.pen = b.want
+}
@@ -0,0 +1,242 @@
+package scuf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Profile is a set of colors supported by terminal
+type Profile int
+
+const (
+	// TrueColor supports 24-bit RGB colors, modifiers are written as is
+	TrueColor Profile = iota
+	// ANSI256 supports 8-bit colors, RGB colors are converted to nearest ANSI-256 color
+	ANSI256
+	// ANSI16 supports 4-bit colors, RGB and ANSI-256 colors are converted to nearest basic ANSI color
+	ANSI16
+	// Ascii supports no colors and styles at all, no SGR and hyperlink sequences are written
+	Ascii
+)
+
+// rgb values of ANSI colors, parsed from ansiHex
+var ansiRGB = func() [len(ansiHex)][3]uint8 {
+	var res [len(ansiHex)][3]uint8
+	for i, hex := range ansiHex {
+		c, _ := parseHex(hex)
+		res[i] = [3]uint8{c.r, c.g, c.b}
+	}
+	return res
+}()
+
+// OKLab values of ANSI colors
+var ansiLab = func() [len(ansiRGB)]oklab {
+	var res [len(ansiRGB)]oklab
+	for i, c := range ansiRGB {
+		res[i] = rgbToOKLab(c[0], c[1], c[2])
+	}
+	return res
+}()
+
+// nearestANSI finds index of ANSI color in range [from, to) perceptually closest to given rgb color.
+// Grays are mapped only to grays.
+func nearestANSI(r, g, b uint8, from, to int) int {
+	lab := rgbToOKLab(r, g, b)
+	isGray := lab.chroma() < achromaticChroma
+
+	best, bestDist := -1, 0.0
+	for i := from; i < to; i++ {
+		if isGray && ansiLab[i].chroma() >= achromaticChroma {
+			continue
+		}
+
+		if dist := lab.dist(ansiLab[i]); best == -1 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// ConvertColor converts color to nearest one supported by profile. ANSI256 profile uses
+// only 16-255 colors for conversion, since first 16 colors are usually redefined by terminal theme.
+// Ascii profile supports no colors, so no color is returned.
+func (p Profile) ConvertColor(c Color) Color {
+	switch {
+	case p == Ascii:
+		return Color{}
+	case !c.isConcrete(),
+		p == TrueColor,
+		p == ANSI256 && c.kind != colorRGB,
+		p == ANSI16 && c.kind == colorANSI16:
+		return c
+	case p == ANSI256:
+		return ANSIColor(nearestANSI(c.r, c.g, c.b, 16, 256))
+	default:
+		r, g, b := c.RGB()
+		return ANSIColor(nearestANSI(r, g, b, 0, 16))
+	}
+}
+
+// Convert modifier to one supported by profile. Colors not supported by profile
+// are replaced with nearest supported ones. Underline styles are supported only by TrueColor
+// profile, otherwise they fall back to plain underline. Underline colors are dropped in ANSI16
+// profile, since they exist only in extended form. Returns nil if nothing is left.
+func (p Profile) Convert(mod Modifier) Modifier {
+	switch {
+	case p == TrueColor || len(mod) == 0:
+		return mod
+	case p == Ascii:
+		return nil
+	}
+
+	params := bytes.Split(mod, []byte{';'})
+	res := make(Modifier, 0, len(mod))
+	for i := 0; i < len(params); i++ {
+		m := Modifier(params[i])
+		switch code := string(params[i]); {
+		case code == "58" && p == ANSI16:
+			m = nil
+			i += colorParams(params, i)
+		case code == "38" || code == "48" || code == "58":
+			convert := func(c Color) Modifier {
+				c = p.ConvertColor(c)
+				switch code {
+				case "38":
+					return c.Fg()
+				case "48":
+					return c.Bg()
+				default:
+					return c.Underline()
+				}
+			}
+			switch colorParams(params, i) {
+			case 2:
+				c, _ := strconv.Atoi(string(params[i+2]))
+				m = convert(ANSIColor(c))
+			case 4:
+				r, _ := strconv.Atoi(string(params[i+2]))
+				g, _ := strconv.Atoi(string(params[i+3]))
+				b, _ := strconv.Atoi(string(params[i+4]))
+				m = convert(RGBColor(uint8(r), uint8(g), uint8(b)))
+			}
+			i += colorParams(params, i)
+		case code == "4:0":
+			m = ModUnderlineOff
+		case strings.HasPrefix(code, "4:"):
+			m = ModUnderline
+		}
+
+		if len(m) == 0 {
+			continue
+		}
+
+		if len(res) > 0 {
+			res = append(res, ';')
+		}
+		res = append(res, m...)
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// colorParams returns number of parameters following extended color code params[i],
+// i.e. 2 for "38;5;n" and 4 for "38;2;r;g;b"
+func colorParams[T string | []byte](params []T, i int) int {
+	switch {
+	case i+2 < len(params) && string(params[i+1]) == "5":
+		return 2
+	case i+4 < len(params) && string(params[i+1]) == "2":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ciVars are environment variables set by well-known CI systems, mapped to profiles their log viewers support
+var ciVars = [...]struct {
+	name    string
+	profile Profile
+}{
+	{"GITHUB_ACTIONS", TrueColor},
+	{"GITEA_ACTIONS", TrueColor},
+	{"BUILDKITE", TrueColor},
+	{"GITLAB_CI", ANSI256},
+	{"CIRCLECI", ANSI256},
+	{"TRAVIS", ANSI256},
+	{"APPVEYOR", ANSI16},
+	{"DRONE", ANSI16},
+	{"TEAMCITY_VERSION", ANSI16},
+	{"TF_BUILD", ANSI16},
+	{"CI", ANSI16},
+}
+
+// isTerminal reports whether writer is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// DetectProfile detects color profile supported by writer using environment variables.
+// Usage:
+//
+//	scuf.New(os.Stdout, scuf.WithProfile(scuf.DetectProfile(os.Stdout)))
+func DetectProfile(w io.Writer) Profile {
+	return detectProfile(isTerminal(w), os.Getenv)
+}
+
+func detectProfile(isTTY bool, getenv func(string) string) Profile {
+	// https://no-color.org
+	if getenv("NO_COLOR") != "" {
+		return Ascii
+	}
+
+	// CI logs are not terminals, but their viewers usually support colors
+	ci := Ascii
+	for _, v := range ciVars {
+		if getenv(v.name) != "" {
+			ci = v.profile
+			break
+		}
+	}
+
+	// https://bixense.com/clicolors
+	forced := getenv("CLICOLOR_FORCE") != "" && getenv("CLICOLOR_FORCE") != "0"
+	if !forced && (!isTTY && ci == Ascii || getenv("CLICOLOR") == "0") {
+		return Ascii
+	}
+
+	term := strings.ToLower(getenv("TERM"))
+	if term == "dumb" && !forced {
+		return Ascii
+	}
+
+	switch colorterm := strings.ToLower(getenv("COLORTERM")); {
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return TrueColor
+	case strings.HasSuffix(term, "-direct"),
+		strings.HasPrefix(term, "xterm-kitty"),
+		strings.HasPrefix(term, "wezterm"),
+		strings.HasPrefix(term, "alacritty"),
+		strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "contour"):
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	case ci != Ascii:
+		return ci
+	default:
+		// some colors are supported by almost any terminal
+		return ANSI16
+	}
+}
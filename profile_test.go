@@ -0,0 +1,156 @@
+package scuf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileConvert(t *testing.T) {
+	for name, test := range map[string]struct {
+		profile  Profile
+		mod      Modifier
+		expected Modifier
+	}{
+		"truecolor keeps rgb":     {TrueColor, FgRGB(1, 2, 3), FgRGB(1, 2, 3)},
+		"ansi256 rgb":             {ANSI256, FgRGB(0x87, 0x00, 0xaf), FgANSI(91)},
+		"ansi256 rgb bg":          {ANSI256, BgRGB(0xab, 0xcd, 0xef), BgANSI(153)},
+		"ansi256 keeps 256":       {ANSI256, BgANSI(91), BgANSI(91)},
+		"ansi256 keeps basic":     {ANSI256, FgRed, FgRed},
+		"ansi16 rgb":              {ANSI16, FgRGB(0xff, 0x10, 0x10), FgHiRed},
+		"ansi16 256":              {ANSI16, BgANSI(231), BgHiWhite},
+		"ansi16 keeps attributes": {ANSI16, ModBold, ModBold},
+		"ansi16 combined":         {ANSI16, Combine(ModBold, FgRGB(0, 0, 0x80), ModItalic), Modifier("1;34;3")},
+		"ansi16 invalid index":    {ANSI16, Modifier("38;5;300"), nil},
+		"ascii drops colors":      {Ascii, FgRed, nil},
+		"ascii drops attributes":  {Ascii, ModBold, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.profile.Convert(test.mod))
+		})
+	}
+}
+
+func TestProfileBuffer(t *testing.T) {
+	for name, test := range map[string]struct {
+		profile  Profile
+		expected string
+	}{
+		"truecolor": {TrueColor, "\x1b[38;2;215;0;0;1mred\x1b[0m"},
+		"ansi256":   {ANSI256, "\x1b[38;5;160;1mred\x1b[0m"},
+		"ansi16":    {ANSI16, "\x1b[91;1mred\x1b[0m"},
+		"ascii":     {Ascii, "red"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var bb bytes.Buffer
+			New(&bb, WithProfile(test.profile)).String("red", FgRGB(MustParseHexRGB("#d70000")), ModBold)
+			assert.Equal(t, test.expected, bb.String())
+		})
+	}
+}
+
+func TestProfileAsciiHyperlink(t *testing.T) {
+	var bb bytes.Buffer
+	New(&bb, WithProfile(Ascii)).
+		Hyperlink("http://x", "a").
+		Markup(MustParseMarkup("[bold link=http://x]b[/]"))
+	assert.Equal(t, "ab", bb.String())
+}
+
+func TestDetectProfile(t *testing.T) {
+	for name, test := range map[string]struct {
+		isTTY    bool
+		env      map[string]string
+		expected Profile
+	}{
+		"not a tty":                {false, map[string]string{"TERM": "xterm-256color"}, Ascii},
+		"tty":                      {true, map[string]string{"TERM": "xterm"}, ANSI16},
+		"tty without term":         {true, map[string]string{}, ANSI16},
+		"256 colors":               {true, map[string]string{"TERM": "screen-256color"}, ANSI256},
+		"colorterm":                {true, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, TrueColor},
+		"kitty":                    {true, map[string]string{"TERM": "xterm-kitty"}, TrueColor},
+		"dumb":                     {true, map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"}, Ascii},
+		"no color":                 {true, map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, Ascii},
+		"no color beats force":     {false, map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, Ascii},
+		"clicolor disabled":        {true, map[string]string{"TERM": "xterm-256color", "CLICOLOR": "0"}, Ascii},
+		"clicolor force":           {false, map[string]string{"CLICOLOR_FORCE": "1", "TERM": "xterm-256color"}, ANSI256},
+		"clicolor force zero":      {false, map[string]string{"CLICOLOR_FORCE": "0", "TERM": "xterm-256color"}, Ascii},
+		"clicolor force dumb":      {false, map[string]string{"CLICOLOR_FORCE": "1", "TERM": "dumb"}, ANSI16},
+		"github actions":           {false, map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, TrueColor},
+		"gitlab":                   {false, map[string]string{"CI": "true", "GITLAB_CI": "true"}, ANSI256},
+		"generic ci":               {false, map[string]string{"CI": "true"}, ANSI16},
+		"ci respects term":         {false, map[string]string{"CI": "true", "TERM": "xterm-256color"}, ANSI256},
+		"ci respects dumb":         {false, map[string]string{"CI": "true", "TERM": "dumb"}, Ascii},
+		"ci respects no color":     {false, map[string]string{"GITHUB_ACTIONS": "true", "NO_COLOR": "1"}, Ascii},
+		"ci respects clicolor off": {false, map[string]string{"GITHUB_ACTIONS": "true", "CLICOLOR": "0"}, Ascii},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, detectProfile(test.isTTY, func(k string) string {
+				return test.env[k]
+			}))
+		})
+	}
+}
+
+func TestDetectProfileNotFile(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	for _, v := range ciVars {
+		t.Setenv(v.name, "")
+	}
+	assert.Equal(t, Ascii, DetectProfile(&bytes.Buffer{}))
+}
+
+func TestProfileConvertColor(t *testing.T) {
+	for name, test := range map[string]struct {
+		profile  Profile
+		color    Color
+		expected Color
+	}{
+		"truecolor":                 {TrueColor, RGBColor(1, 2, 3), RGBColor(1, 2, 3)},
+		"ascii":                     {Ascii, ANSIColor(1), Color{}},
+		"none":                      {ANSI16, Color{}, Color{}},
+		"ansi256 exact":             {ANSI256, RGBColor(0x87, 0x00, 0xaf), ANSIColor(91)},
+		"ansi256 keeps basic":       {ANSI256, ANSIColor(3), ANSIColor(3)},
+		"ansi256 gray":              {ANSI256, RGBColor(0x88, 0x88, 0x88), ANSIColor(102)},
+		"ansi256 dark gray":         {ANSI256, RGBColor(0x14, 0x14, 0x14), ANSIColor(233)},
+		"ansi256 near gray":         {ANSI256, RGBColor(0x76, 0x77, 0x76), ANSIColor(243)},
+		"ansi256 orange":            {ANSI256, RGBColor(0xff, 0x88, 0x00), ANSIColor(208)},
+		"ansi16 keeps basic":        {ANSI16, ANSIColor(9), ANSIColor(9)},
+		"ansi16 red":                {ANSI16, RGBColor(0xe8, 0x30, 0x30), ANSIColor(9)},
+		"ansi16 dark gray not navy": {ANSI16, RGBColor(0x30, 0x30, 0x30), ANSIColor(8)},
+		"ansi16 gray":               {ANSI16, RGBColor(0x70, 0x70, 0x70), ANSIColor(8)},
+		"ansi16 light gray":         {ANSI16, RGBColor(0xb0, 0xb0, 0xb0), ANSIColor(7)},
+		"ansi16 from 256":           {ANSI16, ANSIColor(196), ANSIColor(9)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.profile.ConvertColor(test.color))
+		})
+	}
+}
+
+func TestProfileConvertUnderline(t *testing.T) {
+	for name, test := range map[string]struct {
+		profile  Profile
+		mod      Modifier
+		expected Modifier
+	}{
+		"truecolor keeps curly":         {TrueColor, ModUnderlineCurly, ModUnderlineCurly},
+		"truecolor keeps color":         {TrueColor, UnderlineRGB(255, 0, 0), UnderlineRGB(255, 0, 0)},
+		"ansi256 curly":                 {ANSI256, ModUnderlineCurly, ModUnderline},
+		"ansi16 dotted":                 {ANSI16, ModUnderlineDotted, ModUnderline},
+		"ansi16 no underline":           {ANSI16, Modifier("4:0"), ModUnderlineOff},
+		"ansi256 no underline":          {ANSI256, Combine(Modifier("4:0"), FgRed), Combine(ModUnderlineOff, FgRed)},
+		"ansi256 keeps underline color": {ANSI256, UnderlineANSI(196), UnderlineANSI(196)},
+		"ansi256 underline rgb":         {ANSI256, UnderlineRGB(255, 0, 0), UnderlineANSI(196)},
+		"ansi256 underline default":     {ANSI256, ModUnderlineColorDefault, ModUnderlineColorDefault},
+		"ansi16 drops underline color":  {ANSI16, Combine(ModUnderline, UnderlineANSI(196)), ModUnderline},
+		"ansi16 squiggly red":           {ANSI16, Combine(ModUnderlineCurly, UnderlineRGB(255, 0, 0), FgRed), Combine(ModUnderline, FgRed)},
+		"ascii":                         {Ascii, ModUnderlineCurly, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.profile.Convert(test.mod))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package scuf
+
+import (
+	"io"
+	"strings"
+)
+
+// Strip removes escape sequences from string, keeping visible text. Removed are CSI
+// sequences (styles, cursor movement), OSC (hyperlinks, clipboard, notifications, titles),
+// DCS, APC, PM, SOS, SS2/SS3 and other two byte escape sequences.
+func Strip(s string) string {
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(s, _esc)
+		if i == -1 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+
+		sb.WriteString(s[:i])
+		n, _ := escapeLen(s[i:])
+		s = s[i+n:]
+	}
+}
+
+// stripState is state of StripWriter inside escape sequence split across writes
+type stripState int
+
+const (
+	stripText stripState = iota
+	// stripEsc is after ESC
+	stripEsc
+	// stripIntermediate is after intermediate bytes of two byte sequence, e.g. ESC (
+	stripIntermediate
+	// stripCSI is inside CSI sequence
+	stripCSI
+	// stripString is inside string sequence: OSC, DCS, APC, PM or SOS
+	stripString
+	// stripStringEsc is after ESC inside string sequence, which is either ST or start of other sequence
+	stripStringEsc
+	// stripSingleShift is after SS2 or SS3, before shifted character
+	stripSingleShift
+)
+
+// stripWriter removes escape sequences from written data
+type stripWriter struct {
+	w     io.Writer
+	state stripState
+	// osc is set inside OSC sequence, which is terminated also by BEL
+	osc bool
+}
+
+// StripWriter returns writer removing escape sequences like Strip from data before writing it to w.
+// Sequences split across writes are handled without buffering them, so sequences of any length,
+// e.g. clipboard contents, are removed.
+func StripWriter(w io.Writer) io.Writer {
+	return &stripWriter{w: w}
+}
+
+func (w *stripWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, c := range p {
+		if !w.skip(c) {
+			out = append(out, c)
+		}
+	}
+
+	if len(out) > 0 {
+		if _, err := w.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// skip advances state by byte c, returning whether c is part of escape sequence.
+// Sequences are recognized same as by escapeLen.
+func (w *stripWriter) skip(c byte) bool {
+	switch w.state {
+	case stripText:
+		if c == _esc {
+			w.state = stripEsc
+			return true
+		}
+		return false
+	case stripEsc:
+		switch c {
+		case '[':
+			w.state = stripCSI
+		case ']', 'P', '_', '^', 'X':
+			w.state, w.osc = stripString, c == ']'
+		case 'N', 'O':
+			w.state = stripSingleShift
+		default:
+			w.state = stripIntermediate
+			return w.skip(c)
+		}
+		return true
+	case stripIntermediate:
+		switch {
+		case c >= 0x30 && c <= 0x7E:
+			w.state = stripText
+		case c < 0x20 || c > 0x7E:
+			// invalid byte aborts sequence
+			w.state = stripText
+			return w.skip(c)
+		}
+		return true
+	case stripCSI:
+		switch {
+		case c >= 0x40 && c <= 0x7E:
+			w.state = stripText
+		case c < 0x20 || c > 0x7E:
+			// invalid byte aborts sequence
+			w.state = stripText
+			return w.skip(c)
+		}
+		return true
+	case stripString:
+		switch {
+		case c == '\a' && w.osc:
+			w.state = stripText
+		case c == '\n':
+			// newline aborts string and is kept
+			w.state = stripText
+			return false
+		case c == _esc:
+			w.state = stripStringEsc
+		}
+		return true
+	case stripStringEsc:
+		if c == '\\' {
+			w.state = stripText
+			return true
+		}
+		// other escape sequence aborts string
+		w.state = stripEsc
+		return w.skip(c)
+	default: // stripSingleShift
+		w.state = stripText
+		return true
+	}
+}
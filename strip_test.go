@@ -0,0 +1,86 @@
+package scuf
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrip(t *testing.T) {
+	for name, test := range map[string]struct {
+		s        string
+		expected string
+	}{
+		"plain":      {"hello", "hello"},
+		"styles":     {String("hello", ModBold, FgRGB(1, 2, 3)) + " " + String("world", BgBlue), "hello world"},
+		"hyperlink":  {NewString(func(b Buffer) { b.Hyperlink("http://x", "link") }), "link"},
+		"copy":       {NewString(func(b Buffer) { b.String("a").Copy("secret").String("b") }), "ab"},
+		"notify":     {NewString(func(b Buffer) { b.String("a").Notify("title", "body").String("b") }), "ab"},
+		"title":      {NewString(func(b Buffer) { b.SetWindowTitle("title").String("a") }), "a"},
+		"cursor":     {NewString(func(b Buffer) { b.CursorUp(2).String("a").ClearLine() }), "a"},
+		"dcs":        {"a\x1bPq#0;2;0;0;0\x1b\\b", "ab"},
+		"apc":        {"a\x1b_Gf=100;data\x1b\\b", "ab"},
+		"ss3":        {"a\x1bOPb", "ab"},
+		"charset":    {"a\x1b(Bb", "ab"},
+		"unicode":    {"\x1b[31m日本語\x1b[0m 👍", "日本語 👍"},
+		"unfinished": {"ab\x1b]8;;http://x", "ab"},
+		"stray osc":  {"a\x1b]0;oops\nb", "a\nb"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Strip(test.s))
+
+			// writer must strip same when sequences are split at any byte
+			var bb bytes.Buffer
+			w := StripWriter(&bb)
+			for i := 0; i < len(test.s); i++ {
+				w.Write([]byte{test.s[i]})
+			}
+			assert.Equal(t, test.expected, bb.String())
+		})
+	}
+}
+
+func TestStripWriter(t *testing.T) {
+	s := NewString(func(b Buffer) {
+		b.String("hello", FgRed).SPC().Hyperlink("http://x", "link").Copy("secret").String(" world", ModBold)
+	})
+
+	// write by every chunk size to split sequences at all positions
+	for size := 1; size <= len(s); size++ {
+		var bb bytes.Buffer
+		w := StripWriter(&bb)
+		for i := 0; i < len(s); i += size {
+			chunk := s[i:min(i+size, len(s))]
+			n, err := w.Write([]byte(chunk))
+			assert.NoError(t, err)
+			assert.Equal(t, len(chunk), n)
+		}
+		assert.Equal(t, "hello link world", bb.String(), "chunk size %d", size)
+	}
+}
+
+func TestStripWriterErr(t *testing.T) {
+	w := StripWriter(&limitedWriter{limit: 2})
+	_, err := w.Write([]byte("\x1b[1mabc"))
+	assert.Error(t, err)
+}
+
+func TestStripWriterStray(t *testing.T) {
+	var bb bytes.Buffer
+	w := StripWriter(&bb)
+	w.Write([]byte("a\x1b]0;oops"))
+	w.Write([]byte("\nb"))
+	w.Write([]byte("c\n"))
+	assert.Equal(t, "a\nbc\n", bb.String())
+}
+
+func TestStripWriterLongSequence(t *testing.T) {
+	var bb bytes.Buffer
+	w := bufio.NewWriterSize(StripWriter(&bb), 16)
+	New(w).String("a").Copy(strings.Repeat("x", 3000)).String("b")
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "ab", bb.String())
+}
@@ -0,0 +1,441 @@
+package scuf
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Attr is text attribute set by SGR, e.g. bold or underline
+type Attr int
+
+const (
+	AttrBold Attr = iota
+	AttrFaint
+	AttrItalic
+	AttrUnderline
+	AttrUnderlineDouble
+	AttrUnderlineCurly
+	AttrUnderlineDotted
+	AttrUnderlineDashed
+	AttrDoublyUnderlined
+	AttrBlink
+	AttrRapidBlink
+	AttrReverse
+	AttrConceal
+	AttrCrossout
+	AttrFont1
+	AttrFont2
+	AttrFont3
+	AttrFont4
+	AttrFont5
+	AttrFont6
+	AttrFont7
+	AttrFont8
+	AttrFont9
+	AttrProportional
+	AttrFramed
+	AttrEncircled
+	AttrOverline
+	AttrSuperscript
+	AttrSubscript
+)
+
+// attrCodes are SGR codes of attributes turning them on and off.
+// Exclusive attribute replaces other attributes having same off code.
+var attrCodes = [...]struct {
+	on, off   string
+	exclusive bool
+}{
+	AttrBold:             {"1", "22", false},
+	AttrFaint:            {"2", "22", false},
+	AttrItalic:           {"3", "23", false},
+	AttrUnderline:        {"4", "24", true},
+	AttrUnderlineDouble:  {"4:2", "24", true},
+	AttrUnderlineCurly:   {"4:3", "24", true},
+	AttrUnderlineDotted:  {"4:4", "24", true},
+	AttrUnderlineDashed:  {"4:5", "24", true},
+	AttrDoublyUnderlined: {"21", "24", true},
+	AttrBlink:            {"5", "25", true},
+	AttrRapidBlink:       {"6", "25", true},
+	AttrReverse:          {"7", "27", false},
+	AttrConceal:          {"8", "28", false},
+	AttrCrossout:         {"9", "29", false},
+	AttrFont1:            {"11", "10", true},
+	AttrFont2:            {"12", "10", true},
+	AttrFont3:            {"13", "10", true},
+	AttrFont4:            {"14", "10", true},
+	AttrFont5:            {"15", "10", true},
+	AttrFont6:            {"16", "10", true},
+	AttrFont7:            {"17", "10", true},
+	AttrFont8:            {"18", "10", true},
+	AttrFont9:            {"19", "10", true},
+	AttrProportional:     {"26", "50", false},
+	AttrFramed:           {"51", "54", true},
+	AttrEncircled:        {"52", "54", true},
+	AttrOverline:         {"53", "55", false},
+	AttrSuperscript:      {"73", "75", true},
+	AttrSubscript:        {"74", "75", true},
+}
+
+// Style is set of SGR attributes and colors. Each attribute and color is either
+// turned on, explicitly turned off or not set at all. Style is immutable, zero value is
+// empty style, e.g. Style{}.Bold().Fg(ANSIColor(1)) is bold red style.
+type Style struct {
+	// reset is set if style starts with full reset
+	reset bool
+	// on and off are bitsets of attributes turned on and off, i-th bit is for Attr(i)
+	on, off uint64
+	// fg, bg, ul are foreground, background and underline colors, no color if not set
+	fg, bg, ul Color
+}
+
+// setAttr turns attribute on or off
+func (s *Style) setAttr(a Attr, on bool) {
+	if !on || attrCodes[a].exclusive {
+		// turn off attribute and all ones having same off code
+		for i, attr := range attrCodes {
+			if attr.off == attrCodes[a].off && (!on || Attr(i) != a) {
+				s.on &^= 1 << i
+				s.off |= 1 << i
+			}
+		}
+	}
+
+	if on {
+		s.on |= 1 << a
+		s.off &^= 1 << a
+	}
+}
+
+// ParseSGR parses modifier, possibly combined from several ones, into style.
+// Both semicolon and colon separated extended colors are supported, e.g. "38;2;1;2;3" and "38:2::1:2:3".
+func ParseSGR(mod Modifier) (Style, error) {
+	var s Style
+	if len(mod) == 0 {
+		return s, nil
+	}
+
+	params := strings.Split(string(mod), ";")
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		if strings.Contains(param, ":") {
+			if err := s.applySubParams(strings.Split(param, ":")); err != nil {
+				return Style{}, fmt.Errorf("parse SGR %q: %w", mod, err)
+			}
+			continue
+		}
+
+		code := 0
+		if param != "" {
+			var err error
+			if code, err = strconv.Atoi(param); err != nil {
+				return Style{}, fmt.Errorf("parse SGR %q: invalid parameter %q", mod, param)
+			}
+		}
+
+		switch {
+		case code == 0:
+			s = Style{reset: true}
+		case code >= 30 && code <= 37:
+			s.fg = ANSIColor(code - 30)
+		case code >= 90 && code <= 97:
+			s.fg = ANSIColor(code - 90 + 8)
+		case code >= 40 && code <= 47:
+			s.bg = ANSIColor(code - 40)
+		case code >= 100 && code <= 107:
+			s.bg = ANSIColor(code - 100 + 8)
+		case code == 39:
+			s.fg = DefaultColor
+		case code == 49:
+			s.bg = DefaultColor
+		case code == 59:
+			s.ul = DefaultColor
+		case code == 38 || code == 48 || code == 58:
+			n := colorParams(params, i)
+			if n == 0 {
+				return Style{}, fmt.Errorf("parse SGR %q: incomplete color after %d", mod, code)
+			}
+
+			c, err := parseSGRColor(params[i+1 : i+n+1])
+			if err != nil {
+				return Style{}, fmt.Errorf("parse SGR %q: %w", mod, err)
+			}
+			s.setColor(code, c)
+			i += n
+		default:
+			if !s.applyAttrCode(param) {
+				return Style{}, fmt.Errorf("parse SGR %q: unsupported parameter %q", mod, param)
+			}
+		}
+	}
+	return s, nil
+}
+
+// applyAttrCode applies attribute on or off code, returns false if code is unknown
+func (s *Style) applyAttrCode(code string) bool {
+	for i, attr := range attrCodes {
+		switch code {
+		case attr.on:
+			s.setAttr(Attr(i), true)
+			return true
+		case attr.off:
+			s.setAttr(Attr(i), false)
+			return true
+		}
+	}
+	return false
+}
+
+// applySubParams applies colon separated parameter, e.g. "4:3" or "38:2::1:2:3"
+func (s *Style) applySubParams(sub []string) error {
+	switch sub[0] {
+	case "4":
+		if len(sub) != 2 || len(sub[1]) != 1 || sub[1][0] < '0' || sub[1][0] > '5' {
+			return fmt.Errorf("invalid underline style %q", strings.Join(sub, ":"))
+		}
+
+		switch sub[1] {
+		case "0":
+			s.setAttr(AttrUnderline, false)
+		case "1":
+			s.setAttr(AttrUnderline, true)
+		default:
+			s.applyAttrCode("4:" + sub[1])
+		}
+		return nil
+	case "38", "48", "58":
+		args := sub[1:]
+		if len(args) == 5 && args[0] == "2" {
+			// skip color space id: 38:2:<color space>:r:g:b
+			args = append([]string{"2"}, args[2:]...)
+		}
+
+		c, err := parseSGRColor(args)
+		if err != nil {
+			return err
+		}
+
+		code, _ := strconv.Atoi(sub[0])
+		s.setColor(code, c)
+		return nil
+	default:
+		return fmt.Errorf("unsupported parameter %q", strings.Join(sub, ":"))
+	}
+}
+
+// parseSGRColor parses arguments of extended color, i.e. "5;n" or "2;r;g;b"
+func parseSGRColor(args []string) (Color, error) {
+	values := make([]int, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < 0 || v > 255 {
+			return Color{}, fmt.Errorf("invalid color component %q", arg)
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case args[0] == "5" && len(values) == 1:
+		return ANSIColor(values[0]), nil
+	case args[0] == "2" && len(values) == 3:
+		return RGBColor(uint8(values[0]), uint8(values[1]), uint8(values[2])), nil
+	default:
+		return Color{}, fmt.Errorf("invalid color %q", strings.Join(args, ";"))
+	}
+}
+
+// setColor sets color by its SGR code: 38 for foreground, 48 for background, 58 for underline
+func (s *Style) setColor(code int, c Color) {
+	switch code {
+	case 38:
+		s.fg = c
+	case 48:
+		s.bg = c
+	case 58:
+		s.ul = c
+	}
+}
+
+// IsReset checks whether style starts with full reset, so all attributes and colors not set are default
+func (s Style) IsReset() bool {
+	return s.reset
+}
+
+// Has checks whether attribute is turned on
+func (s Style) Has(a Attr) bool {
+	return s.on&(1<<a) != 0
+}
+
+// HasOff checks whether attribute is explicitly turned off
+func (s Style) HasOff(a Attr) bool {
+	return s.off&(1<<a) != 0
+}
+
+// Foreground returns foreground color, no color if it is not set
+func (s Style) Foreground() Color {
+	return s.fg
+}
+
+// Background returns background color, no color if it is not set
+func (s Style) Background() Color {
+	return s.bg
+}
+
+// UnderlineColor returns underline color, no color if it is not set
+func (s Style) UnderlineColor() Color {
+	return s.ul
+}
+
+// Modifier encodes style into single modifier
+func (s Style) Modifier() Modifier {
+	res := []string{}
+	if s.reset {
+		res = append(res, "0")
+	}
+
+	for i, attr := range attrCodes {
+		switch {
+		case s.off&(1<<i) == 0,
+			s.reset, // everything is already off after reset
+			slices.Contains(res, attr.off):
+			continue
+		}
+
+		// exclusive attribute turned on replaces others, no need to turn them off
+		replaced := false
+		for j, other := range attrCodes {
+			if other.exclusive && other.off == attr.off && s.on&(1<<j) != 0 {
+				replaced = true
+			}
+		}
+		if !replaced {
+			res = append(res, attr.off)
+		}
+	}
+
+	for i, attr := range attrCodes {
+		if s.on&(1<<i) != 0 {
+			res = append(res, attr.on)
+		}
+	}
+
+	for _, c := range [...]struct {
+		color Color
+		mod   func(Color) Modifier
+	}{{s.fg, Color.Fg}, {s.bg, Color.Bg}, {s.ul, Color.Underline}} {
+		// default color is already set after reset
+		if c.color.kind != colorDefault || !s.reset {
+			if mod := c.mod(c.color); len(mod) > 0 {
+				res = append(res, string(mod))
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+	return Modifier(strings.Join(res, ";"))
+}
+
+// With returns style with attribute turned on
+func (s Style) With(a Attr) Style {
+	s.setAttr(a, true)
+	return s
+}
+
+// Without returns style with attribute explicitly turned off
+func (s Style) Without(a Attr) Style {
+	s.setAttr(a, false)
+	return s
+}
+
+// Bold returns style with bold turned on
+func (s Style) Bold() Style {
+	return s.With(AttrBold)
+}
+
+// Faint returns style with faint turned on
+func (s Style) Faint() Style {
+	return s.With(AttrFaint)
+}
+
+// Italic returns style with italic turned on
+func (s Style) Italic() Style {
+	return s.With(AttrItalic)
+}
+
+// Underline returns style with underline turned on
+func (s Style) Underline() Style {
+	return s.With(AttrUnderline)
+}
+
+// Blink returns style with blink turned on
+func (s Style) Blink() Style {
+	return s.With(AttrBlink)
+}
+
+// Reverse returns style with reverse turned on
+func (s Style) Reverse() Style {
+	return s.With(AttrReverse)
+}
+
+// Crossout returns style with crossout turned on
+func (s Style) Crossout() Style {
+	return s.With(AttrCrossout)
+}
+
+// Overline returns style with overline turned on
+func (s Style) Overline() Style {
+	return s.With(AttrOverline)
+}
+
+// Fg returns style with foreground color
+func (s Style) Fg(c Color) Style {
+	s.fg = c
+	return s
+}
+
+// Bg returns style with background color
+func (s Style) Bg(c Color) Style {
+	s.bg = c
+	return s
+}
+
+// Ul returns style with underline color
+func (s Style) Ul(c Color) Style {
+	s.ul = c
+	return s
+}
+
+// Merge returns style with attributes and colors set in other style overriding ones in s
+func (s Style) Merge(other Style) Style {
+	if other.reset {
+		return other
+	}
+
+	set := other.on | other.off
+	s.on = s.on&^set | other.on
+	s.off = s.off&^set | other.off
+	if !other.fg.IsNone() {
+		s.fg = other.fg
+	}
+	if !other.bg.IsNone() {
+		s.bg = other.bg
+	}
+	if !other.ul.IsNone() {
+		s.ul = other.ul
+	}
+	return s
+}
+
+// Inherit returns style with attributes and colors not set in s taken from parent
+func (s Style) Inherit(parent Style) Style {
+	return parent.Merge(s)
+}
+
+// Render returns string styled with style
+func (s Style) Render(str string) string {
+	return String(str, s.Modifier())
+}
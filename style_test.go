@@ -0,0 +1,155 @@
+package scuf
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSGR(t *testing.T) {
+	for name, test := range map[string]struct {
+		mod     Modifier
+		on, off []Attr
+		fg, bg  Color
+		ul      Color
+		reset   bool
+		encoded Modifier
+	}{
+		"empty": {mod: nil},
+		"bold":  {mod: ModBold, on: []Attr{AttrBold}, encoded: ModBold},
+		"combined": {
+			mod:     Combine(FgRGB(MustParseHexRGB("#abcdef")), BgANSI(69), ModBold, ModItalic, ModFaint, ModUnderline, ModBlink),
+			on:      []Attr{AttrBold, AttrFaint, AttrItalic, AttrUnderline, AttrBlink},
+			off:     []Attr{AttrUnderlineDouble, AttrUnderlineCurly, AttrUnderlineDotted, AttrUnderlineDashed, AttrDoublyUnderlined, AttrRapidBlink},
+			fg:      RGBColor(0xab, 0xcd, 0xef),
+			bg:      ANSIColor(69),
+			encoded: Modifier("1;2;3;4;5;38;2;171;205;239;48;5;69"),
+		},
+		"basic colors": {
+			mod:     Combine(FgHiRed, BgBlue),
+			fg:      ANSIColor(9),
+			bg:      ANSIColor(4),
+			encoded: Modifier("91;44"),
+		},
+		"colon colors": {
+			mod:     Modifier("38:2::1:2:3;48:2:4:5:6;58:5:196"),
+			fg:      RGBColor(1, 2, 3),
+			bg:      RGBColor(4, 5, 6),
+			ul:      ANSIColor(196),
+			encoded: Modifier("38;2;1;2;3;48;2;4;5;6;58;5;196"),
+		},
+		"curly underline with color": {
+			mod:     Combine(ModUnderline, ModUnderlineCurly, UnderlineRGB(255, 0, 0)),
+			on:      []Attr{AttrUnderlineCurly},
+			off:     []Attr{AttrUnderline, AttrUnderlineDouble, AttrUnderlineDotted, AttrUnderlineDashed, AttrDoublyUnderlined},
+			ul:      RGBColor(255, 0, 0),
+			encoded: Modifier("4:3;58;2;255;0;0"),
+		},
+		"off codes": {
+			mod:     Combine(ModBold, ModBoldOff, ModFaint, ModFgDefault, ModItalicOff),
+			on:      []Attr{AttrFaint},
+			off:     []Attr{AttrBold, AttrItalic},
+			fg:      DefaultColor,
+			encoded: Modifier("22;23;2;39"),
+		},
+		"underline off by colon": {
+			mod:     Modifier("4:0"),
+			off:     []Attr{AttrUnderline, AttrUnderlineDouble, AttrUnderlineCurly, AttrUnderlineDotted, AttrUnderlineDashed, AttrDoublyUnderlined},
+			encoded: ModUnderlineOff,
+		},
+		"reset": {
+			mod:     Combine(ModBold, FgRed, ModReset, ModItalic),
+			on:      []Attr{AttrItalic},
+			reset:   true,
+			encoded: Modifier("0;3"),
+		},
+		"empty parameter is reset": {
+			mod:     Modifier(";1"),
+			on:      []Attr{AttrBold},
+			reset:   true,
+			encoded: Modifier("0;1"),
+		},
+		"font": {
+			mod:     Combine(Font(2), Font(5)),
+			on:      []Attr{AttrFont5},
+			off:     []Attr{AttrFont1, AttrFont2, AttrFont3, AttrFont4, AttrFont6, AttrFont7, AttrFont8, AttrFont9},
+			encoded: Font(5),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSGR(test.mod)
+			assert.NoError(t, err)
+			for a := AttrBold; a <= AttrSubscript; a++ {
+				assert.Equal(t, slices.Contains(test.on, a), s.Has(a), "on %d", a)
+				assert.Equal(t, slices.Contains(test.off, a), s.HasOff(a), "off %d", a)
+			}
+			assert.Equal(t, test.fg, s.Foreground())
+			assert.Equal(t, test.bg, s.Background())
+			assert.Equal(t, test.ul, s.UnderlineColor())
+			assert.Equal(t, test.reset, s.IsReset())
+			assert.Equal(t, test.encoded, s.Modifier())
+		})
+	}
+}
+
+func TestParseSGRErrors(t *testing.T) {
+	for mod, expected := range map[string]string{
+		"x":           `parse SGR "x": invalid parameter "x"`,
+		"1;20":        `parse SGR "1;20": unsupported parameter "20"`,
+		"38;5":        `parse SGR "38;5": incomplete color after 38`,
+		"48;2;1;2":    `parse SGR "48;2;1;2": incomplete color after 48`,
+		"38;5;256":    `parse SGR "38;5;256": invalid color component "256"`,
+		"38;3;1;2;3":  `parse SGR "38;3;1;2;3": incomplete color after 38`,
+		"4:6":         `parse SGR "4:6": invalid underline style "4:6"`,
+		"38:2:1:2":    `parse SGR "38:2:1:2": invalid color "2;1;2"`,
+		"38:7:1":      `parse SGR "38:7:1": invalid color "7;1"`,
+		"1:2":         `parse SGR "1:2": unsupported parameter "1:2"`,
+		"58:2::1:x:3": `parse SGR "58:2::1:x:3": invalid color component "x"`,
+	} {
+		t.Run(mod, func(t *testing.T) {
+			_, err := ParseSGR(Modifier(mod))
+			assert.EqualError(t, err, expected)
+		})
+	}
+}
+
+func TestStyleBuilder(t *testing.T) {
+	red, blue := ANSIColor(1), RGBColor(0, 0, 255)
+	base := Style{}.Bold().Fg(red)
+	for name, test := range map[string]struct {
+		style    Style
+		expected Modifier
+	}{
+		"empty":          {Style{}, nil},
+		"base":           {base, Modifier("1;31")},
+		"base unchanged": {func() Style { base.Italic().Bg(blue); return base }(), Modifier("1;31")},
+		"all": {
+			Style{}.Bold().Faint().Italic().Underline().Blink().Reverse().Crossout().Overline().Fg(red).Bg(blue).Ul(red),
+			Modifier("1;2;3;4;5;7;9;53;31;48;2;0;0;255;58;5;1"),
+		},
+		"without":       {base.Without(AttrBold), Modifier("22;31")},
+		"with curly":    {base.Underline().With(AttrUnderlineCurly), Modifier("1;4:3;31")},
+		"merge":         {base.Merge(Style{}.Italic().Fg(blue)), Modifier("1;3;38;2;0;0;255")},
+		"merge off":     {base.Merge(Style{}.Without(AttrBold)), Modifier("22;31")},
+		"merge empty":   {base.Merge(Style{}), Modifier("1;31")},
+		"merge default": {base.Merge(Style{}.Fg(DefaultColor)), Modifier("1;39")},
+		"merge reset":   {base.Merge(Style{reset: true}.Italic()), Modifier("0;3")},
+		"inherit":       {Style{}.Fg(blue).Without(AttrBold).Inherit(base.Italic()), Modifier("22;3;38;2;0;0;255")},
+		"inherit empty": {Style{}.Inherit(base), Modifier("1;31")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.style.Modifier())
+		})
+	}
+}
+
+func TestStyleRender(t *testing.T) {
+	assert.Equal(t, "\x1b[1;34mfoo\x1b[0m", Style{}.Bold().Fg(ANSIColor(1)).Merge(Style{}.Fg(ANSIColor(4))).Render("foo"))
+	assert.Equal(t, "foo", Style{}.Render("foo"))
+	assert.Equal(t, "\x1b[3mfoo\x1b[0m", NewString(func(b Buffer) {
+		b.StyledWith(func(b Buffer) {
+			b.String("foo")
+		}, Style{}.Italic())
+	}))
+}
@@ -0,0 +1,88 @@
+package scuf
+
+import "strings"
+
+// Truncate shortens string containing escape sequences to at most width cells, ending it with tail.
+// Wide characters are never cut, style and hyperlink left open by cut are closed.
+func Truncate(s string, width int, tail string) string {
+	width = max(width, 0)
+	if Width(s) <= width {
+		return s
+	}
+
+	// tail is truncated if it doesn't fit
+	if Width(tail) > width {
+		s, tail = tail, ""
+	}
+
+	var sb strings.Builder
+	var state escapeState
+	col, limit := 0, width-Width(tail)
+	for s != "" {
+		if s[0] == _esc {
+			n, _ := escapeLen(s)
+			state.apply(s[:n])
+			sb.WriteString(s[:n])
+			s = s[n:]
+			continue
+		}
+
+		n := graphemeLen(s)
+		w := graphemeWidth(s[:n])
+		if col+w > limit {
+			break
+		}
+		sb.WriteString(s[:n])
+		col += w
+		s = s[n:]
+	}
+
+	sb.WriteString(tail)
+	state.scan(tail)
+	sb.WriteString(state.close())
+	return sb.String()
+}
+
+// Slice returns part of string containing escape sequences between display columns start
+// and end, end is exclusive. Style and hyperlink active at start are reopened, ones left open
+// at end are closed. Parts of wide characters cut by column bounds are replaced with spaces.
+func Slice(s string, start, end int) string {
+	var sb strings.Builder
+	var state escapeState
+	opened := false
+	col := 0
+	for s != "" && col < end {
+		if s[0] == _esc {
+			n, _ := escapeLen(s)
+			state.apply(s[:n])
+			if opened {
+				sb.WriteString(s[:n])
+			}
+			s = s[n:]
+			continue
+		}
+
+		n := graphemeLen(s)
+		g, w := s[:n], graphemeWidth(s[:n])
+		s = s[n:]
+		if col+w > start && !opened {
+			sb.WriteString(state.open())
+			opened = true
+		}
+
+		switch {
+		case col+w <= start:
+		case col < start || col+w > end:
+			// wide character is cut
+			sb.WriteString(strings.Repeat(" ", min(col+w, end)-max(col, start)))
+		default:
+			sb.WriteString(g)
+		}
+		col += w
+	}
+
+	if opened {
+		sb.WriteString(state.close())
+	}
+	return sb.String()
+}
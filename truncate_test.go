@@ -0,0 +1,62 @@
+package scuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	for name, test := range map[string]struct {
+		s        string
+		width    int
+		tail     string
+		expected string
+	}{
+		"fits":             {"hello", 5, "…", "hello"},
+		"plain":            {"hello world", 8, "…", "hello w…"},
+		"no tail":          {"hello world", 5, "", "hello"},
+		"long tail":        {"hello world", 2, "...", ".."},
+		"zero width":       {"abc", 0, "…", ""},
+		"negative width":   {"abc", -1, "…", ""},
+		"negative empty":   {"", -5, "…", ""},
+		"styled":           {String("hello world", FgRed), 6, "…", "\x1b[31mhello…\x1b[0m"},
+		"style ended":      {String("hi", ModBold) + " there", 5, "…", "\x1b[1mhi\x1b[0m t…"},
+		"styled tail":      {"hello world", 6, String("…", FgRed), "hello\x1b[31m…\x1b[0m"},
+		"wide not cut":     {"日本語テキスト", 6, "…", "日本…"},
+		"wide exact":       {"日本語テキスト", 7, "…", "日本語…"},
+		"emoji":            {"👍👍👍", 4, "", "👍👍"},
+		"combining":        {"ééé", 2, "", "éé"},
+		"hyperlink closed": {NewString(func(b Buffer) { b.Hyperlink("http://x", "long link") }), 4, "", "\x1b]8;;http://x\x1b\\long\x1b]8;;\x1b\\"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Truncate(test.s, test.width, test.tail))
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	for name, test := range map[string]struct {
+		s          string
+		start, end int
+		expected   string
+	}{
+		"plain":           {"hello world", 2, 7, "llo w"},
+		"from start":      {"hello", 0, 2, "he"},
+		"beyond end":      {"hello", 3, 10, "lo"},
+		"empty":           {"hello", 3, 3, ""},
+		"style reopened":  {String("hello", FgRed), 1, 3, "\x1b[31mel\x1b[0m"},
+		"style inside":    {"ab" + String("cd", ModBold) + "ef", 1, 5, "b\x1b[1mcd\x1b[0me"},
+		"nested styles":   {"\x1b[1mab\x1b[31mcd\x1b[0mef", 3, 5, "\x1b[1;31md\x1b[0me"},
+		"style ended":     {String("ab", FgRed) + "cd", 2, 4, "cd"},
+		"wide cut start":  {"日本語", 1, 6, " 本語"},
+		"wide cut end":    {"日本語", 0, 3, "日 "},
+		"wide cut both":   {"日本語", 1, 5, " 本 "},
+		"hyperlink":       {NewString(func(b Buffer) { b.Hyperlink("http://x", "link") }), 1, 3, "\x1b]8;;http://x\x1b\\in\x1b]8;;\x1b\\"},
+		"escapes skipped": {"\x1b]2;title\aabc", 1, 2, "b"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Slice(test.s, test.start, test.end))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package scuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWidth(t *testing.T) {
+	for name, test := range map[string]struct {
+		s        string
+		expected int
+	}{
+		"empty":              {"", 0},
+		"ascii":              {"hello", 5},
+		"styled":             {String("hello", ModBold, FgRGB(1, 2, 3)), 5},
+		"nested":             {NewString(func(b Buffer) { b.Styled(func(b Buffer) { b.String("ab", FgRed).String("c") }, ModBold) }), 3},
+		"hyperlink":          {NewString(func(b Buffer) { b.Hyperlink("https://example.com/very/long", "link") }), 4},
+		"osc bel":            {"\x1b]2;title\atext", 4},
+		"cjk":                {"日本語", 6},
+		"fullwidth":          {"ＡＢ", 4},
+		"hangul":             {"한국어", 6},
+		"hangul jamo":        {"각", 2},
+		"combining":          {"éé", 2},
+		"lone combining":     {"\u0301", 0},
+		"emoji":              {"😀", 2},
+		"emoji skin tone":    {"👍🏽", 2},
+		"zwj sequence":       {"👨\u200d👩\u200d👧", 2},
+		"flag":               {"🇯🇵", 2},
+		"emoji presentation": {"❤\ufe0f", 2},
+		"text presentation":  {"❤", 1},
+		"control":            {"a\tb\n", 2},
+		"zero width joiner":  {"a\u200db", 2},
+		"mixed":              {"a日\x1b[31mb😀\x1b[0m", 6},
+		"unterminated csi":   {"ab\x1b[31", 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Width(test.s))
+		})
+	}
+}
+
+func TestEscapeLen(t *testing.T) {
+	for s, expected := range map[string]struct {
+		n        int
+		complete bool
+	}{
+		"\x1b[0;1mx":         {6, true},
+		"\x1b[?25lx":         {6, true},
+		"\x1b[31":            {4, false},
+		"\x1b]8;;url\x1b\\x": {10, true},
+		"\x1b]2;title\ax":    {10, true},
+		"\x1b]8;;url":        {8, false},
+		"\x1b]8;;url\x1b":    {9, false},
+		"\x1b]0;oops\nx":     {8, true},
+		"\x1b]8;;url\x1b[0m": {8, true},
+		"\x1bPq#0\x1b\\x":    {7, true},
+		"\x1b_data\x1b\\x":   {8, true},
+		"\x1bOPx":            {3, true},
+		"\x1bN":              {2, false},
+		"\x1b(Bx":            {3, true},
+		"\x1b7x":             {2, true},
+		"\x1b":               {1, false},
+		"\x1b\x00":           {1, true},
+	} {
+		n, complete := escapeLen(s)
+		assert.Equal(t, expected.n, n, "%q", s)
+		assert.Equal(t, expected.complete, complete, "%q", s)
+	}
+}
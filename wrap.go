@@ -0,0 +1,155 @@
+package scuf
+
+import "strings"
+
+type wrapConfig struct {
+	hardBreak        bool
+	indent           string
+	preserveNewlines bool
+}
+
+// WrapOption configures Wrap
+type WrapOption func(*wrapConfig)
+
+// WrapHardBreak breaks words longer than width, by default they overflow line
+func WrapHardBreak() WrapOption {
+	return func(c *wrapConfig) {
+		c.hardBreak = true
+	}
+}
+
+// WrapIndent sets hanging indent, which is written at start of each wrapped line
+func WrapIndent(indent string) WrapOption {
+	return func(c *wrapConfig) {
+		c.indent = indent
+	}
+}
+
+// WrapPreserveNewlines keeps newlines in text, by default they are treated as spaces
+func WrapPreserveNewlines() WrapOption {
+	return func(c *wrapConfig) {
+		c.preserveNewlines = true
+	}
+}
+
+// wrapSegment is grapheme, escape sequence, whitespace or newline
+type wrapSegment struct {
+	text  string
+	width int
+	// escape is set for escape sequences
+	escape bool
+}
+
+// wrapper writes wrapped text, tracking style and hyperlink to reopen them on each line
+type wrapper struct {
+	wrapConfig
+	sb    strings.Builder
+	width int
+	// line is width of current line, empty is set if no text is written on it yet
+	line  int
+	empty bool
+	state escapeState
+}
+
+// Wrap wraps text containing escape sequences to lines of at most width cells, breaking on spaces.
+// Active style and hyperlink are closed at end of each line and reopened at start of next one.
+func Wrap(s string, width int, opts ...WrapOption) string {
+	w := wrapper{width: max(width, 1), empty: true}
+	for _, opt := range opts {
+		opt(&w.wrapConfig)
+	}
+
+	var word, spaces []wrapSegment
+	wordWidth, spacesWidth := 0, 0
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+
+		if !w.empty && w.line+spacesWidth+wordWidth > w.width {
+			w.newline(true)
+			w.emitEscapes(spaces)
+		} else {
+			for _, seg := range spaces {
+				w.emit(seg)
+			}
+		}
+		for _, seg := range word {
+			if w.hardBreak && !seg.escape && !w.empty && w.line+seg.width > w.width {
+				w.newline(true)
+			}
+			w.emit(seg)
+		}
+		word, spaces = nil, nil
+		wordWidth, spacesWidth = 0, 0
+	}
+
+	for s != "" {
+		var seg wrapSegment
+		if s[0] == _esc {
+			n, _ := escapeLen(s)
+			seg = wrapSegment{text: s[:n], escape: true}
+		} else {
+			n := graphemeLen(s)
+			seg = wrapSegment{text: s[:n], width: graphemeWidth(s[:n])}
+		}
+		s = s[len(seg.text):]
+
+		switch {
+		case (seg.text == "\n" || seg.text == "\r\n") && w.preserveNewlines:
+			flushWord()
+			// trailing spaces are dropped
+			w.emitEscapes(spaces)
+			spaces, spacesWidth = nil, 0
+			w.newline(false)
+		case seg.text == " " || seg.text == "\t" || seg.text == "\n" || seg.text == "\r\n":
+			flushWord()
+			spaces = append(spaces, wrapSegment{text: " ", width: 1})
+			spacesWidth++
+		case seg.escape && len(word) == 0:
+			// escape between words is written with spaces preceding it
+			spaces = append(spaces, seg)
+		default:
+			word = append(word, seg)
+			wordWidth += seg.width
+		}
+	}
+	flushWord()
+	w.emitEscapes(spaces)
+	return w.sb.String()
+}
+
+// emitEscapes writes only escape sequences of segments
+func (w *wrapper) emitEscapes(segs []wrapSegment) {
+	for _, seg := range segs {
+		if seg.escape {
+			w.emit(seg)
+		}
+	}
+}
+
+// emit writes segment, tracking style and hyperlink
+func (w *wrapper) emit(seg wrapSegment) {
+	w.sb.WriteString(seg.text)
+	if !seg.escape {
+		w.line += seg.width
+		w.empty = false
+		return
+	}
+
+	w.state.apply(seg.text)
+}
+
+// newline ends current line, closing style and hyperlink, and starts next one reopening them.
+// Indent is written if line is wrapped.
+func (w *wrapper) newline(wrapped bool) {
+	w.sb.WriteString(w.state.close())
+	w.sb.WriteByte('\n')
+
+	w.line, w.empty = 0, true
+	if wrapped && w.indent != "" {
+		w.sb.WriteString(w.indent)
+		w.line = Width(w.indent)
+	}
+	w.sb.WriteString(w.state.open())
+}
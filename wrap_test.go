@@ -0,0 +1,93 @@
+package scuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	for name, test := range map[string]struct {
+		s        string
+		width    int
+		opts     []WrapOption
+		expected string
+	}{
+		"plain": {
+			"the quick brown fox jumps", 10, nil,
+			"the quick\nbrown fox\njumps",
+		},
+		"fits": {
+			"hello", 10, nil,
+			"hello",
+		},
+		"exact": {
+			"abc def", 7, nil,
+			"abc def",
+		},
+		"collapses newlines": {
+			"abc\ndef ghi", 8, nil,
+			"abc def\nghi",
+		},
+		"preserve newlines": {
+			"abc\ndef ghi", 8, []WrapOption{WrapPreserveNewlines()},
+			"abc\ndef ghi",
+		},
+		"trailing spaces dropped": {
+			"abc   \ndef", 8, []WrapOption{WrapPreserveNewlines()},
+			"abc\ndef",
+		},
+		"leading spaces kept": {
+			"  abc def", 6, nil,
+			"  abc\ndef",
+		},
+		"long word overflows": {
+			"a abcdefgh b", 4, nil,
+			"a\nabcdefgh\nb",
+		},
+		"hard break": {
+			"a abcdefgh b", 4, []WrapOption{WrapHardBreak()},
+			"a\nabcd\nefgh\nb",
+		},
+		"indent": {
+			"one two three four", 9, []WrapOption{WrapIndent("  ")},
+			"one two\n  three\n  four",
+		},
+		"wide characters": {
+			"日本語 テキスト", 8, nil,
+			"日本語\nテキスト",
+		},
+		"wide hard break": {
+			"日本語テ", 5, []WrapOption{WrapHardBreak()},
+			"日本\n語テ",
+		},
+		"style reopened": {
+			String("aaa bbb ccc", FgRed), 4, nil,
+			"\x1b[31maaa\x1b[0m\n\x1b[31mbbb\x1b[0m\n\x1b[31mccc\x1b[0m",
+		},
+		"nested style reopened": {
+			"\x1b[1maa \x1b[31mbb\x1b[0m cc", 5, nil,
+			"\x1b[1maa \x1b[31mbb\x1b[0m\ncc",
+		},
+		"style reopened after indent": {
+			"\x1b[1;44maa bb\x1b[0m", 3, []WrapOption{WrapIndent(">")},
+			"\x1b[1;44maa\x1b[0m\n>\x1b[1;44mbb\x1b[0m",
+		},
+		"hyperlink reopened": {
+			NewString(func(b Buffer) { b.Hyperlink("http://x", "aa bb") }), 3, nil,
+			"\x1b]8;;http://x\x1b\\aa\x1b]8;;\x1b\\\n\x1b]8;;http://x\x1b\\bb\x1b]8;;\x1b\\",
+		},
+		"hard break inside style": {
+			"\x1b[32mabcdef\x1b[0m", 3, []WrapOption{WrapHardBreak()},
+			"\x1b[32mabc\x1b[0m\n\x1b[32mdef\x1b[0m",
+		},
+		"preserved newline inside style": {
+			"\x1b[3ma\nb\x1b[0m", 10, []WrapOption{WrapPreserveNewlines()},
+			"\x1b[3ma\x1b[0m\n\x1b[3mb\x1b[0m",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Wrap(test.s, test.width, test.opts...))
+		})
+	}
+}